@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"time"
+)
+
+// CertificateInfo describes a certificate and how long it is valid
+type CertificateInfo struct {
+	Source      string    `json:"source"`
+	Subject     string    `json:"subject"`
+	Issuer      string    `json:"issuer"`
+	DNSNames    []string  `json:"dnsNames,omitempty"`
+	IPAddresses []string  `json:"ipAddresses,omitempty"`
+	URIs        []string  `json:"uris,omitempty"`
+	NotBefore   time.Time `json:"notBefore"`
+	NotAfter    time.Time `json:"notAfter"`
+	DaysLeft    int       `json:"daysLeft"`
+}
+
+// ExpiresWithin tells if the certificate has expired or will expire within threshold
+func (info CertificateInfo) ExpiresWithin(threshold time.Duration) bool {
+	return time.Now().Add(threshold).After(info.NotAfter)
+}
+
+// NewCertificateInfo describes a parsed certificate, source tells where it was loaded from
+func NewCertificateInfo(source string, certificate *x509.Certificate) CertificateInfo {
+	info := CertificateInfo{
+		Source:    source,
+		Subject:   certificate.Subject.String(),
+		Issuer:    certificate.Issuer.String(),
+		DNSNames:  certificate.DNSNames,
+		NotBefore: certificate.NotBefore,
+		NotAfter:  certificate.NotAfter,
+		DaysLeft:  int(time.Until(certificate.NotAfter).Hours() / 24),
+	}
+
+	for _, ip := range certificate.IPAddresses {
+		info.IPAddresses = append(info.IPAddresses, ip.String())
+	}
+
+	for _, uri := range certificate.URIs {
+		info.URIs = append(info.URIs, uri.String())
+	}
+
+	return info
+}
+
+// InspectCertificates describes every certificate in the PEM files at paths, a path can be a certificate bundle, the certificate
+// of a key pair, a directory or a glob pattern. An error is returned if a path contains no certificates.
+func InspectCertificates(paths ...string) (infos []CertificateInfo, err error) {
+	var result []CertificateInfo
+
+	for _, path := range paths {
+		var filenames []string
+		filenames, err = expandTrustStorePath(path)
+		if err != nil {
+			return
+		}
+
+		found := false
+
+		for _, filename := range filenames {
+			var content []byte
+			content, err = ioutil.ReadFile(filename)
+			if err != nil {
+				return
+			}
+
+			for _, certificate := range parsePEMCertificates(content) {
+				result = append(result, NewCertificateInfo(filename, certificate))
+				found = true
+			}
+		}
+
+		if !found {
+			err = errors.New("No certificates found in " + path)
+			return
+		}
+	}
+
+	infos = result
+
+	return
+}
+
+// FilterExpiringCertificates returns the certificates that have expired or will expire within threshold
+func FilterExpiringCertificates(infos []CertificateInfo, threshold time.Duration) (expiring []CertificateInfo) {
+	for _, info := range infos {
+		if info.ExpiresWithin(threshold) {
+			expiring = append(expiring, info)
+		}
+	}
+
+	return
+}
@@ -0,0 +1,52 @@
+package utils_test
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mojlighetsministeriet/utils"
+	"github.com/mojlighetsministeriet/utils/tlstest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInspectCertificates(test *testing.T) {
+	authority, err := tlstest.NewCertificateAuthority()
+	assert.NoError(test, err)
+	authority.Validity = time.Hour
+
+	first, err := authority.IssueServerCertificate("first")
+	assert.NoError(test, err)
+
+	second, err := authority.IssueServerCertificate("second")
+	assert.NoError(test, err)
+
+	bundle := append(first.CertificatePEM, second.CertificatePEM...)
+	assert.NoError(test, ioutil.WriteFile("/tmp/an-inspected-bundle.pem", bundle, 0600))
+	defer os.Remove("/tmp/an-inspected-bundle.pem")
+
+	infos, err := utils.InspectCertificates("/tmp/an-inspected-bundle.pem")
+	assert.NoError(test, err)
+	assert.Equal(test, 2, len(infos))
+	assert.Equal(test, "/tmp/an-inspected-bundle.pem", infos[0].Source)
+	assert.Equal(test, "CN=first", infos[0].Subject)
+	assert.Equal(test, "CN=tlstest certificate authority", infos[0].Issuer)
+	assert.Equal(test, []string{"first"}, infos[0].DNSNames)
+	assert.Equal(test, 0, infos[0].DaysLeft)
+
+	assert.Equal(test, false, infos[0].ExpiresWithin(time.Minute))
+	assert.Equal(test, true, infos[0].ExpiresWithin(24*time.Hour))
+	assert.Equal(test, 2, len(utils.FilterExpiringCertificates(infos, 24*time.Hour)))
+	assert.Equal(test, 0, len(utils.FilterExpiringCertificates(infos, time.Minute)))
+}
+
+func TestFailInspectCertificatesWithoutCertificates(test *testing.T) {
+	assert.NoError(test, ioutil.WriteFile("/tmp/not-an-inspected-certificate.pem", []byte("nothing here"), 0600))
+	defer os.Remove("/tmp/not-an-inspected-certificate.pem")
+
+	infos, err := utils.InspectCertificates("/tmp/not-an-inspected-certificate.pem")
+	assert.Error(test, err)
+	assert.Equal(test, "No certificates found in /tmp/not-an-inspected-certificate.pem", err.Error())
+	assert.Nil(test, infos)
+}
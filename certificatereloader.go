@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"crypto/tls"
+	"sync"
+	"time"
+)
+
+// CertificateReloader keeps a certificate and key pair up to date when the files are renewed on disk, use it through
+// GetCertificate and GetClientCertificate in a tls.Config or with GetReloadingServerTLSConfig and GetReloadingClientTLSConfig
+type CertificateReloader struct {
+	mutex           sync.RWMutex
+	certificate     *tls.Certificate
+	certificateFile *WatchedFile
+	keyFile         *WatchedFile
+}
+
+// NewCertificateReloader loads a PEM encoded certificate and key pair and reloads it when one of the files changes, interval is how often the files are checked
+func NewCertificateReloader(certificateFilename, keyFilename string, interval time.Duration) (reloader *CertificateReloader, err error) {
+	result := &CertificateReloader{}
+
+	result.certificateFile, err = NewWatchedFile(certificateFilename, interval)
+	if err != nil {
+		return
+	}
+
+	result.keyFile, err = NewWatchedFile(keyFilename, interval)
+	if err != nil {
+		result.certificateFile.Close()
+		return
+	}
+
+	err = result.Reload()
+	if err != nil {
+		result.Close()
+		return
+	}
+
+	// The certificate and key are seldom written at the exact same time, a mismatched pair keeps the previous certificate until the other file is written
+	result.certificateFile.OnChange(func(content []byte) { result.Reload() })
+	result.keyFile.OnChange(func(content []byte) { result.Reload() })
+
+	reloader = result
+
+	return
+}
+
+// Reload parses the current contents of the certificate and key files, the previous certificate is kept if they can not be parsed
+func (reloader *CertificateReloader) Reload() (err error) {
+	certificate, err := tls.X509KeyPair(reloader.certificateFile.Bytes(), reloader.keyFile.Bytes())
+	if err != nil {
+		return
+	}
+
+	reloader.mutex.Lock()
+	reloader.certificate = &certificate
+	reloader.mutex.Unlock()
+
+	return
+}
+
+// Certificate returns the current certificate
+func (reloader *CertificateReloader) Certificate() *tls.Certificate {
+	reloader.mutex.RLock()
+	defer reloader.mutex.RUnlock()
+
+	return reloader.certificate
+}
+
+// GetCertificate can be used as tls.Config.GetCertificate for servers
+func (reloader *CertificateReloader) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return reloader.Certificate(), nil
+}
+
+// GetClientCertificate can be used as tls.Config.GetClientCertificate for mutual TLS clients
+func (reloader *CertificateReloader) GetClientCertificate(request *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return reloader.Certificate(), nil
+}
+
+// Close stops watching the certificate and key files
+func (reloader *CertificateReloader) Close() {
+	reloader.certificateFile.Close()
+	reloader.keyFile.Close()
+}
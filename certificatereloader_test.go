@@ -0,0 +1,172 @@
+package utils_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mojlighetsministeriet/utils"
+	"github.com/mojlighetsministeriet/utils/tlstest"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeKeyPair(test *testing.T, commonName string) {
+	authority, err := tlstest.NewCertificateAuthority()
+	assert.NoError(test, err)
+
+	certificate, err := authority.IssueServerCertificate(commonName)
+	assert.NoError(test, err)
+	assert.NoError(test, certificate.WriteFiles("/tmp/a-reloaded-certificate.pem", "/tmp/a-reloaded-key.pem"))
+
+	later := time.Now().Add(time.Minute)
+	os.Chtimes("/tmp/a-reloaded-certificate.pem", later, later)
+	os.Chtimes("/tmp/a-reloaded-key.pem", later, later)
+}
+
+func getCommonName(test *testing.T, certificate *tls.Certificate) string {
+	leaf, err := x509.ParseCertificate(certificate.Certificate[0])
+	assert.NoError(test, err)
+	return leaf.Subject.CommonName
+}
+
+func TestCertificateReloader(test *testing.T) {
+	writeKeyPair(test, "first")
+	defer os.Remove("/tmp/a-reloaded-certificate.pem")
+	defer os.Remove("/tmp/a-reloaded-key.pem")
+
+	reloader, err := utils.NewCertificateReloader("/tmp/a-reloaded-certificate.pem", "/tmp/a-reloaded-key.pem", 10*time.Millisecond)
+	assert.NoError(test, err)
+	defer reloader.Close()
+
+	certificate, err := reloader.GetCertificate(nil)
+	assert.NoError(test, err)
+	assert.Equal(test, "first", getCommonName(test, certificate))
+
+	writeKeyPair(test, "second")
+
+	deadline := time.Now().Add(time.Second)
+	for getCommonName(test, reloader.Certificate()) != "second" && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	certificate, err = reloader.GetClientCertificate(nil)
+	assert.NoError(test, err)
+	assert.Equal(test, "second", getCommonName(test, certificate))
+}
+
+func TestFailNewCertificateReloaderWithMissingKey(test *testing.T) {
+	writeKeyPair(test, "first")
+	defer os.Remove("/tmp/a-reloaded-certificate.pem")
+	os.Remove("/tmp/a-reloaded-key.pem")
+
+	reloader, err := utils.NewCertificateReloader("/tmp/a-reloaded-certificate.pem", "/tmp/a-reloaded-key.pem", time.Second)
+	assert.Error(test, err)
+	assert.Nil(test, reloader)
+}
+
+func TestGetReloadingClientTLSConfigVerifiesAgainstCurrentTrustStore(test *testing.T) {
+	authority, err := tlstest.NewCertificateAuthority()
+	assert.NoError(test, err)
+
+	anotherAuthority, err := tlstest.NewCertificateAuthority()
+	assert.NoError(test, err)
+
+	serverCertificate, err := authority.IssueServerCertificate("a-server")
+	assert.NoError(test, err)
+
+	assert.NoError(test, anotherAuthority.WriteCertificateFile("/tmp/a-reloaded-ca.pem"))
+	defer os.Remove("/tmp/a-reloaded-ca.pem")
+
+	rootCAs, err := utils.NewTrustStoreReloader(0, false, "/tmp/a-reloaded-ca.pem")
+	assert.NoError(test, err)
+	defer rootCAs.Close()
+
+	config := utils.GetReloadingClientTLSConfig(nil, rootCAs)
+	state := tls.ConnectionState{ServerName: "a-server", PeerCertificates: []*x509.Certificate{serverCertificate.Certificate.Leaf}}
+	assert.Error(test, config.VerifyConnection(state))
+
+	assert.NoError(test, authority.WriteCertificateFile("/tmp/a-reloaded-ca.pem"))
+	assert.NoError(test, rootCAs.Reload())
+	assert.NoError(test, config.VerifyConnection(state))
+}
+
+func TestGetReloadingClientTLSConfigVerifiesServerName(test *testing.T) {
+	authority, err := tlstest.NewCertificateAuthority()
+	assert.NoError(test, err)
+	assert.NoError(test, authority.WriteCertificateFile("/tmp/a-reloaded-ca.pem"))
+	defer os.Remove("/tmp/a-reloaded-ca.pem")
+
+	rootCAs, err := utils.NewTrustStoreReloader(0, false, "/tmp/a-reloaded-ca.pem")
+	assert.NoError(test, err)
+	defer rootCAs.Close()
+
+	otherHostCertificate, err := authority.IssueServerCertificate("some-other-host.example")
+	assert.NoError(test, err)
+
+	ipCertificate, err := authority.IssueServerCertificate("127.0.0.1")
+	assert.NoError(test, err)
+
+	for _, current := range []struct {
+		certificate *tlstest.IssuedCertificate
+		serverName  string
+		valid       bool
+	}{
+		{otherHostCertificate, "", false},
+		{otherHostCertificate, "127.0.0.1", false},
+		{ipCertificate, "", false},
+		{ipCertificate, "127.0.0.1", true},
+	} {
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {}))
+		server.TLS = current.certificate.ServerTLSConfig()
+		server.StartTLS()
+
+		config := utils.GetReloadingClientTLSConfig(nil, rootCAs)
+		config.ServerName = current.serverName
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: config}}
+
+		response, err := client.Get(server.URL)
+		if current.valid {
+			assert.NoError(test, err)
+			response.Body.Close()
+		} else {
+			assert.Error(test, err)
+		}
+
+		server.Close()
+	}
+}
+
+func TestGetReloadingClientTLSConfigWithoutTrustStore(test *testing.T) {
+	config := utils.GetReloadingClientTLSConfig(nil, nil)
+	assert.Equal(test, false, config.InsecureSkipVerify)
+	assert.Nil(test, config.VerifyConnection)
+}
+
+func TestGetReloadingServerTLSConfig(test *testing.T) {
+	writeKeyPair(test, "a-server")
+	defer os.Remove("/tmp/a-reloaded-certificate.pem")
+	defer os.Remove("/tmp/a-reloaded-key.pem")
+
+	certificate, err := utils.NewCertificateReloader("/tmp/a-reloaded-certificate.pem", "/tmp/a-reloaded-key.pem", 0)
+	assert.NoError(test, err)
+	defer certificate.Close()
+
+	clientCAs, err := utils.NewTrustStoreReloader(0, false, "/tmp/a-reloaded-certificate.pem")
+	assert.NoError(test, err)
+
+	config := utils.GetReloadingServerTLSConfig(certificate, clientCAs)
+	assert.Equal(test, tls.RequireAndVerifyClientCert, config.ClientAuth)
+
+	handshakeConfig, err := config.GetConfigForClient(nil)
+	assert.NoError(test, err)
+	assert.Equal(test, clientCAs.Pool(), handshakeConfig.ClientCAs)
+	assert.Nil(test, handshakeConfig.GetConfigForClient)
+
+	served, err := handshakeConfig.GetCertificate(nil)
+	assert.NoError(test, err)
+	assert.Equal(test, "a-server", getCommonName(test, served))
+}
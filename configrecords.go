@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ConfigRecord describes a configuration value that has been read through one of the utils config helpers
+type ConfigRecord struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+	Secret bool   `json:"secret"`
+}
+
+// MaskedConfigValue replaces the value of secret config records
+const MaskedConfigValue = "********"
+
+// Keys containing any of these words are treated as secret even if they have not been marked with MarkConfigSecret
+var secretConfigKeyWords = []string{"PASSWORD", "SECRET", "TOKEN", "PRIVATE"}
+
+var configRecords = struct {
+	sync.RWMutex
+	records    map[string]ConfigRecord
+	secretKeys map[string]bool
+}{
+	records:    make(map[string]ConfigRecord),
+	secretKeys: make(map[string]bool),
+}
+
+// MarkConfigSecret marks keys (environment variable names or file paths) whose values should always be masked by GetConfigRecords
+func MarkConfigSecret(keys ...string) {
+	configRecords.Lock()
+	defer configRecords.Unlock()
+
+	for _, key := range keys {
+		configRecords.secretKeys[key] = true
+	}
+}
+
+// GetConfigRecords returns every configuration value that has been read so far ordered by key. Values that are marked
+// as secret, read from files or have keys that look like passwords are masked.
+func GetConfigRecords() (records []ConfigRecord) {
+	configRecords.RLock()
+	defer configRecords.RUnlock()
+
+	records = []ConfigRecord{}
+
+	for _, record := range configRecords.records {
+		record.Secret = record.Secret || configRecords.secretKeys[record.Key] || looksLikeSecretConfigKey(record.Key)
+		if record.Secret && record.Value != "" {
+			record.Value = MaskedConfigValue
+		}
+
+		records = append(records, record)
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Key < records[j].Key
+	})
+
+	return
+}
+
+func recordConfigValue(key string, value string, source string, secret bool) {
+	configRecords.Lock()
+	defer configRecords.Unlock()
+
+	configRecords.records[key] = ConfigRecord{Key: key, Value: value, Source: source, Secret: secret}
+}
+
+func looksLikeSecretConfigKey(key string) bool {
+	upperCaseKey := strings.ToUpper(key)
+
+	for _, word := range secretConfigKeyWords {
+		if strings.Contains(upperCaseKey, word) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,299 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// ConfigSource describes where a configuration value came from, the sources are listed in order of increasing precedence
+type ConfigSource int
+
+const (
+	// ConfigSourceDefault is a value set with SetDefault or the default value of a flag
+	ConfigSourceDefault ConfigSource = iota
+	// ConfigSourceFile is a value read from a JSON or YAML config file
+	ConfigSourceFile
+	// ConfigSourceDotEnv is a value read from a .env file
+	ConfigSourceDotEnv
+	// ConfigSourceEnvironment is a value read from an environment variable
+	ConfigSourceEnvironment
+	// ConfigSourceFlag is a value set with a command line flag
+	ConfigSourceFlag
+)
+
+var configSourceNames = []string{"default", "file", "dotenv", "environment", "flag"}
+
+// String returns the name of the source
+func (source ConfigSource) String() string {
+	if source < 0 || int(source) >= len(configSourceNames) {
+		return "unknown"
+	}
+
+	return configSourceNames[source]
+}
+
+// MarshalText encodes the source as its name e.g. when encoding a ConfigValue to JSON
+func (source ConfigSource) MarshalText() ([]byte, error) {
+	return []byte(source.String()), nil
+}
+
+// ConfigValue is a resolved configuration value together with where it came from
+type ConfigValue struct {
+	Key    string       `json:"key"`
+	Value  string       `json:"value"`
+	Source ConfigSource `json:"source"`
+	Origin string       `json:"origin,omitempty"`
+	Secret bool         `json:"secret,omitempty"`
+}
+
+// ConfigRegistry merges configuration from defaults, config files, .env files, environment variables and flags.
+// A value from a source with higher precedence always wins, no matter in which order the sources are loaded. Once LoadEnvironment has been called
+// keys that are added later are also looked up in the environment, and a value set with SetDefault is never replaced by the default value of a flag.
+// Keys are normalized to environment variable style so that smtp.host, smtp-host and SMTP_HOST are the same key.
+type ConfigRegistry struct {
+	mutex             sync.RWMutex
+	values            map[string]ConfigValue
+	environmentLoaded bool
+}
+
+// NewConfigRegistry creates an empty ConfigRegistry
+func NewConfigRegistry() *ConfigRegistry {
+	return &ConfigRegistry{values: make(map[string]ConfigValue)}
+}
+
+// SetDefault sets the default value for a key, this also makes the key known to LoadEnvironment. It replaces the default value of a flag with the same name.
+func (registry *ConfigRegistry) SetDefault(key, value string) {
+	registry.set(key, value, ConfigSourceDefault, "", false)
+}
+
+// LoadFile reads a JSON or YAML (.yaml or .yml) config file, nested objects are flattened so that {"smtp":{"host":"x"}} becomes SMTP_HOST.
+// Use os.IsNotExist on the returned error to treat the file as optional.
+func (registry *ConfigRegistry) LoadFile(path string) (err error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+
+	var data interface{}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(content, &data)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &data)
+	default:
+		err = errors.New("Unsupported config file format " + path)
+	}
+
+	if err != nil {
+		return
+	}
+
+	values := make(map[string]string)
+	flattenConfigData("", data, values)
+
+	for key, value := range values {
+		registry.set(key, value, ConfigSourceFile, path, false)
+	}
+
+	return
+}
+
+// LoadDotEnv reads variables from one or more .env files, see the package level LoadDotEnv for the supported format.
+// Use os.IsNotExist on the returned error to treat the files as optional.
+func (registry *ConfigRegistry) LoadDotEnv(paths ...string) (err error) {
+	for _, path := range paths {
+		var values map[string]string
+		values, err = readDotEnvFile(path)
+		if err != nil {
+			return
+		}
+
+		for key, value := range values {
+			registry.set(key, value, ConfigSourceDotEnv, path, false)
+		}
+	}
+
+	return
+}
+
+// LoadEnvironment reads the environment variable (or KEY_FILE, see GetEnv) for every key that is known to the registry, keys that are added later are read when they are added.
+// Values read from KEY_FILE are secret since that is how secrets are mounted, they have KEY_FILE as origin and are always masked by GetConfigRecords.
+func (registry *ConfigRegistry) LoadEnvironment() {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	registry.environmentLoaded = true
+
+	for key := range registry.values {
+		registry.setFromEnvironment(key)
+	}
+}
+
+// LoadFlags registers the default value of every flag, unless the key already has a value, and the value of every flag that was explicitly set on the command line.
+// flagSet must already be parsed.
+func (registry *ConfigRegistry) LoadFlags(flagSet *flag.FlagSet) {
+	flagSet.VisitAll(func(currentFlag *flag.Flag) {
+		registry.setIfMissing(currentFlag.Name, currentFlag.DefValue, ConfigSourceDefault, "-"+currentFlag.Name)
+	})
+
+	flagSet.Visit(func(currentFlag *flag.Flag) {
+		registry.set(currentFlag.Name, currentFlag.Value.String(), ConfigSourceFlag, "-"+currentFlag.Name, false)
+	})
+}
+
+// Get returns the value for a key or an empty string if it is not set
+func (registry *ConfigRegistry) Get(key string) string {
+	value, _ := registry.Lookup(key)
+	return value.Value
+}
+
+// Lookup returns the value for a key together with where it came from
+func (registry *ConfigRegistry) Lookup(key string) (value ConfigValue, found bool) {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	value, found = registry.values[normalizeConfigKey(key)]
+	if found {
+		source := value.Source.String()
+		if value.Secret && value.Source == ConfigSourceEnvironment {
+			// Recorded the same way as GetEnv records a value read from KEY_FILE
+			source = ConfigSourceFile.String()
+		}
+
+		recordConfigValue(value.Key, value.Value, source, value.Secret)
+	}
+
+	return
+}
+
+// Keys returns all known keys in alphabetical order
+func (registry *ConfigRegistry) Keys() (keys []string) {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	for key := range registry.values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return
+}
+
+// Values returns all values ordered by key, useful to answer where a setting came from
+func (registry *ConfigRegistry) Values() (values []ConfigValue) {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	for _, value := range registry.values {
+		values = append(values, value)
+	}
+
+	sort.Slice(values, func(i, j int) bool {
+		return values[i].Key < values[j].Key
+	})
+
+	return
+}
+
+func (registry *ConfigRegistry) set(key string, value string, source ConfigSource, origin string, secret bool) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	registry.store(normalizeConfigKey(key), value, source, origin, secret)
+}
+
+func (registry *ConfigRegistry) setIfMissing(key string, value string, source ConfigSource, origin string) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	key = normalizeConfigKey(key)
+	if _, found := registry.values[key]; found {
+		return
+	}
+
+	registry.store(key, value, source, origin, false)
+}
+
+// store sets a value unless the key has a value from a source with higher precedence, the mutex must be locked and key normalized
+func (registry *ConfigRegistry) store(key string, value string, source ConfigSource, origin string, secret bool) {
+	existing, found := registry.values[key]
+	if found && existing.Source > source {
+		return
+	}
+
+	registry.values[key] = ConfigValue{Key: key, Value: value, Source: source, Origin: origin, Secret: secret}
+
+	if !found && registry.environmentLoaded && source < ConfigSourceEnvironment {
+		registry.setFromEnvironment(key)
+	}
+}
+
+// setFromEnvironment sets a key from its environment variable or KEY_FILE if one of them is set, the mutex must be locked
+func (registry *ConfigRegistry) setFromEnvironment(key string) {
+	value, source := lookupEnv(key)
+	if value == "" {
+		return
+	}
+
+	if source == ConfigSourceFile.String() {
+		registry.store(key, value, ConfigSourceEnvironment, key+"_FILE", true)
+	} else {
+		registry.store(key, value, ConfigSourceEnvironment, key, false)
+	}
+}
+
+func normalizeConfigKey(key string) string {
+	key = strings.Replace(key, ".", "_", -1)
+	key = strings.Replace(key, "-", "_", -1)
+	return strings.ToUpper(strings.TrimSpace(key))
+}
+
+func flattenConfigData(prefix string, data interface{}, values map[string]string) {
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "_" + key
+	}
+
+	switch typed := data.(type) {
+	case map[string]interface{}:
+		for key, value := range typed {
+			flattenConfigData(join(key), value, values)
+		}
+	case map[interface{}]interface{}:
+		for key, value := range typed {
+			flattenConfigData(join(fmt.Sprint(key)), value, values)
+		}
+	case []interface{}:
+		items := make([]string, len(typed))
+		for i, item := range typed {
+			items[i] = formatConfigData(item)
+		}
+		values[prefix] = strings.Join(items, ",")
+	case nil:
+		values[prefix] = ""
+	default:
+		values[prefix] = formatConfigData(typed)
+	}
+}
+
+func formatConfigData(data interface{}) string {
+	if number, isFloat := data.(float64); isFloat {
+		return strconv.FormatFloat(number, 'f', -1, 64)
+	}
+
+	return fmt.Sprint(data)
+}
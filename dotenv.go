@@ -0,0 +1,383 @@
+package utils
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// LoadDotEnv reads one or more .env files (.env in the working directory if no path is given) and sets the variables
+// in the environment so that GetEnv and GetEnvInt can read them. Variables that are already set in the environment are
+// never overridden, if several files set the same variable the last file wins.
+//
+// The format follows docker-compose: comments, export prefixes, single quoted (literal) and double quoted (escaped,
+// possibly multi-line) values as well as ${VAR}, ${VAR:-default}, ${VAR-default} and $VAR interpolation.
+func LoadDotEnv(paths ...string) (err error) {
+	if len(paths) == 0 {
+		paths = []string{".env"}
+	}
+
+	values := make(map[string]string)
+
+	for _, path := range paths {
+		var content []byte
+		content, err = ioutil.ReadFile(path)
+		if err != nil {
+			return
+		}
+
+		err = parseDotEnv(string(content), values)
+		if err != nil {
+			err = errors.New(path + ": " + err.Error())
+			return
+		}
+	}
+
+	for key, value := range values {
+		if _, isSet := os.LookupEnv(key); !isSet {
+			os.Setenv(key, value)
+		}
+	}
+
+	return
+}
+
+// ParseDotEnv parses the content of a .env file into a map without changing the environment
+func ParseDotEnv(reader io.Reader) (values map[string]string, err error) {
+	content, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return
+	}
+
+	result := make(map[string]string)
+	err = parseDotEnv(string(content), result)
+	if err == nil {
+		values = result
+	}
+
+	return
+}
+
+func readDotEnvFile(path string) (values map[string]string, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	values, err = ParseDotEnv(file)
+	if err != nil {
+		err = errors.New(path + ": " + err.Error())
+	}
+
+	return
+}
+
+type dotEnvParser struct {
+	input    string
+	position int
+	line     int
+	values   map[string]string
+}
+
+func parseDotEnv(input string, values map[string]string) (err error) {
+	parser := &dotEnvParser{input: input, line: 1, values: values}
+
+	for {
+		parser.skipBlankLinesAndComments()
+		if parser.position >= len(parser.input) {
+			return
+		}
+
+		err = parser.parseLine()
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (parser *dotEnvParser) parseLine() (err error) {
+	parser.skipWhitespace()
+	if strings.HasPrefix(parser.input[parser.position:], "export ") || strings.HasPrefix(parser.input[parser.position:], "export\t") {
+		parser.position += len("export")
+		parser.skipWhitespace()
+	}
+
+	start := parser.position
+	for parser.position < len(parser.input) && isDotEnvKeyCharacter(parser.input[parser.position]) {
+		parser.position++
+	}
+	key := parser.input[start:parser.position]
+
+	if key == "" {
+		return parser.error("expected a variable name")
+	}
+
+	parser.skipWhitespace()
+
+	// A line with only a variable name passes the variable on from the environment, which is already the case here
+	if parser.atEndOfLine() {
+		parser.skipRestOfLine()
+		return
+	}
+
+	if parser.input[parser.position] != '=' {
+		return parser.error("expected = after " + key)
+	}
+	parser.position++
+	parser.skipWhitespace()
+
+	var value string
+
+	if parser.position < len(parser.input) && parser.input[parser.position] == '"' {
+		value, err = parser.parseDoubleQuotedValue()
+	} else if parser.position < len(parser.input) && parser.input[parser.position] == '\'' {
+		value, err = parser.parseSingleQuotedValue()
+	} else {
+		value, err = parser.parseUnquotedValue()
+	}
+
+	if err != nil {
+		return
+	}
+
+	parser.skipWhitespace()
+	if !parser.atEndOfLine() {
+		return parser.error("unexpected characters after the value of " + key)
+	}
+	parser.skipRestOfLine()
+
+	parser.values[key] = value
+
+	return
+}
+
+func (parser *dotEnvParser) parseSingleQuotedValue() (value string, err error) {
+	line := parser.line
+	parser.position++
+
+	end := strings.IndexByte(parser.input[parser.position:], '\'')
+	if end < 0 {
+		parser.line = line
+		err = parser.error("unterminated single quoted value")
+		return
+	}
+
+	value = parser.input[parser.position : parser.position+end]
+	parser.line += strings.Count(value, "\n")
+	parser.position += end + 1
+
+	return
+}
+
+func (parser *dotEnvParser) parseDoubleQuotedValue() (value string, err error) {
+	line := parser.line
+	parser.position++
+	buffer := make([]byte, 0, 64)
+
+	for parser.position < len(parser.input) {
+		character := parser.input[parser.position]
+
+		switch character {
+		case '"':
+			parser.position++
+			value = string(buffer)
+			return
+
+		case '\\':
+			if parser.position+1 < len(parser.input) {
+				parser.position++
+				buffer = append(buffer, unescapeDotEnvCharacter(parser.input[parser.position])...)
+				parser.position++
+				continue
+			}
+
+		case '$':
+			var expanded string
+			expanded, parser.position, err = parser.expandVariable(parser.input, parser.position)
+			if err != nil {
+				return
+			}
+			buffer = append(buffer, expanded...)
+			continue
+
+		case '\n':
+			parser.line++
+		}
+
+		buffer = append(buffer, character)
+		parser.position++
+	}
+
+	parser.line = line
+	err = parser.error("unterminated double quoted value")
+
+	return
+}
+
+func (parser *dotEnvParser) parseUnquotedValue() (value string, err error) {
+	start := parser.position
+	for parser.position < len(parser.input) && parser.input[parser.position] != '\n' {
+		if parser.input[parser.position] == '#' && parser.position > start && isDotEnvWhitespace(parser.input[parser.position-1]) {
+			break
+		}
+		parser.position++
+	}
+
+	raw := strings.TrimSpace(parser.input[start:parser.position])
+	buffer := make([]byte, 0, len(raw))
+
+	for i := 0; i < len(raw); {
+		if raw[i] != '$' {
+			buffer = append(buffer, raw[i])
+			i++
+			continue
+		}
+
+		var expanded string
+		expanded, i, err = parser.expandVariable(raw, i)
+		if err != nil {
+			return
+		}
+		buffer = append(buffer, expanded...)
+	}
+
+	value = string(buffer)
+
+	return
+}
+
+// expandVariable resolves the reference that starts with the $ at input[start] and returns the value and the position after the reference
+func (parser *dotEnvParser) expandVariable(input string, start int) (value string, end int, err error) {
+	end = start + 1
+
+	if end < len(input) && input[end] == '$' {
+		value = "$"
+		end++
+		return
+	}
+
+	if end < len(input) && input[end] == '{' {
+		closing := strings.IndexByte(input[end:], '}')
+		if closing < 0 {
+			err = parser.error("unterminated variable reference")
+			return
+		}
+
+		expression := input[end+1 : end+closing]
+		end += closing + 1
+
+		if index := strings.Index(expression, ":-"); index >= 0 {
+			value, _ = parser.lookup(expression[:index])
+			if value == "" {
+				value = expression[index+2:]
+			}
+			return
+		}
+
+		if index := strings.IndexByte(expression, '-'); index >= 0 {
+			var found bool
+			value, found = parser.lookup(expression[:index])
+			if !found {
+				value = expression[index+1:]
+			}
+			return
+		}
+
+		value, _ = parser.lookup(expression)
+		return
+	}
+
+	nameEnd := end
+	for nameEnd < len(input) && isDotEnvVariableCharacter(input[nameEnd]) {
+		nameEnd++
+	}
+
+	if nameEnd == end {
+		value = "$"
+		return
+	}
+
+	value, _ = parser.lookup(input[end:nameEnd])
+	end = nameEnd
+
+	return
+}
+
+// lookup prefers the real environment since it is never overridden by the .env files
+func (parser *dotEnvParser) lookup(name string) (value string, found bool) {
+	value, found = os.LookupEnv(name)
+	if !found {
+		value, found = parser.values[name]
+	}
+
+	return
+}
+
+func (parser *dotEnvParser) skipWhitespace() {
+	for parser.position < len(parser.input) && isDotEnvWhitespace(parser.input[parser.position]) {
+		parser.position++
+	}
+}
+
+func (parser *dotEnvParser) skipBlankLinesAndComments() {
+	for parser.position < len(parser.input) {
+		character := parser.input[parser.position]
+
+		switch {
+		case character == '\n':
+			parser.line++
+			parser.position++
+		case isDotEnvWhitespace(character):
+			parser.position++
+		case character == '#':
+			parser.skipRestOfLine()
+		default:
+			return
+		}
+	}
+}
+
+func (parser *dotEnvParser) skipRestOfLine() {
+	for parser.position < len(parser.input) && parser.input[parser.position] != '\n' {
+		parser.position++
+	}
+}
+
+func (parser *dotEnvParser) atEndOfLine() bool {
+	return parser.position >= len(parser.input) || parser.input[parser.position] == '\n' || parser.input[parser.position] == '#'
+}
+
+func (parser *dotEnvParser) error(message string) error {
+	return errors.New("line " + strconv.Itoa(parser.line) + ": " + message)
+}
+
+func unescapeDotEnvCharacter(character byte) string {
+	switch character {
+	case 'n':
+		return "\n"
+	case 'r':
+		return "\r"
+	case 't':
+		return "\t"
+	case '"', '\\', '$':
+		return string(character)
+	}
+
+	return "\\" + string(character)
+}
+
+func isDotEnvWhitespace(character byte) bool {
+	return character == ' ' || character == '\t' || character == '\r'
+}
+
+func isDotEnvKeyCharacter(character byte) bool {
+	return isDotEnvVariableCharacter(character) || character == '.' || character == '-'
+}
+
+func isDotEnvVariableCharacter(character byte) bool {
+	return character == '_' || (character >= 'a' && character <= 'z') || (character >= 'A' && character <= 'Z') || (character >= '0' && character <= '9')
+}
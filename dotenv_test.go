@@ -0,0 +1,75 @@
+package utils_test
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mojlighetsministeriet/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+const dotEnvFixture = `
+# A comment
+export DOTENV_HOST=example.com # an inline comment
+DOTENV_URL=https://${DOTENV_HOST}/api
+DOTENV_HASH=abc#def
+DOTENV_SINGLE='literal ${DOTENV_HOST} \n'
+DOTENV_DOUBLE="line one\nline \"two\" at $DOTENV_HOST"
+DOTENV_MULTILINE="first
+second"
+DOTENV_DEFAULT=${DOTENV_MISSING:-fallback}
+DOTENV_EMPTY=
+DOTENV_ESCAPED="costs \$5 or $$5"
+`
+
+func TestParseDotEnv(test *testing.T) {
+	values, err := utils.ParseDotEnv(strings.NewReader(dotEnvFixture))
+	assert.NoError(test, err)
+	assert.Equal(test, map[string]string{
+		"DOTENV_HOST":      "example.com",
+		"DOTENV_URL":       "https://example.com/api",
+		"DOTENV_HASH":      "abc#def",
+		"DOTENV_SINGLE":    "literal ${DOTENV_HOST} \\n",
+		"DOTENV_DOUBLE":    "line one\nline \"two\" at example.com",
+		"DOTENV_MULTILINE": "first\nsecond",
+		"DOTENV_DEFAULT":   "fallback",
+		"DOTENV_EMPTY":     "",
+		"DOTENV_ESCAPED":   "costs $5 or $5",
+	}, values)
+}
+
+func TestFailParseDotEnvWithUnterminatedQuote(test *testing.T) {
+	_, err := utils.ParseDotEnv(strings.NewReader("A=1\nB=\"never closed\nC=3\n"))
+	assert.Error(test, err)
+	assert.Equal(test, "line 2: unterminated double quoted value", err.Error())
+}
+
+func TestFailParseDotEnvWithMissingEqualSign(test *testing.T) {
+	_, err := utils.ParseDotEnv(strings.NewReader("A=1\nB 2\n"))
+	assert.Error(test, err)
+	assert.Equal(test, "line 2: expected = after B", err.Error())
+}
+
+func TestLoadDotEnvDoesNotOverrideEnvironment(test *testing.T) {
+	err := ioutil.WriteFile("/tmp/load-dot-env.env", []byte("LOAD_DOT_ENV_SET=from file\nLOAD_DOT_ENV_UNSET=from file\nLOAD_DOT_ENV_PORT=7000\n"), 0600)
+	assert.NoError(test, err)
+	defer os.Remove("/tmp/load-dot-env.env")
+
+	os.Setenv("LOAD_DOT_ENV_SET", "from environment")
+	defer os.Unsetenv("LOAD_DOT_ENV_SET")
+	defer os.Unsetenv("LOAD_DOT_ENV_UNSET")
+	defer os.Unsetenv("LOAD_DOT_ENV_PORT")
+
+	err = utils.LoadDotEnv("/tmp/load-dot-env.env")
+	assert.NoError(test, err)
+	assert.Equal(test, "from environment", utils.GetEnv("LOAD_DOT_ENV_SET", ""))
+	assert.Equal(test, "from file", utils.GetEnv("LOAD_DOT_ENV_UNSET", ""))
+	assert.Equal(test, 7000, utils.GetEnvInt("LOAD_DOT_ENV_PORT", 0))
+}
+
+func TestFailLoadDotEnvWithMissingFile(test *testing.T) {
+	err := utils.LoadDotEnv("/tmp/a-missing.env")
+	assert.Equal(test, true, os.IsNotExist(err))
+}
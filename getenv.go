@@ -6,26 +6,48 @@ import (
 	"strconv"
 )
 
-// GetEnv returns an environment variable or a default value
+// GetEnv returns an environment variable or a default value. If the variable is unset but KEY_FILE is set, the contents of that file is used instead
 func GetEnv(key, fallback string) string {
-	value := os.Getenv(key)
+	value, source := lookupEnv(key)
 
 	if len(value) == 0 {
-		return fallback
+		value = fallback
+		source = ConfigSourceDefault.String()
 	}
 
+	recordConfigValue(key, value, source, source == ConfigSourceFile.String())
+
 	return value
 }
 
 // GetEnvInt returns an environment variable or a default value as integer
 func GetEnvInt(key string, fallback int) int {
-	valueAsString := os.Getenv(key)
+	valueAsString, source := lookupEnv(key)
 	pattern := regexp.MustCompile("[^\\d]+")
 	value, err := strconv.Atoi(pattern.ReplaceAllString(valueAsString, ""))
 
 	if valueAsString == "" || err != nil {
-		return fallback
+		value = fallback
+		source = ConfigSourceDefault.String()
 	}
 
+	recordConfigValue(key, strconv.Itoa(value), source, source == ConfigSourceFile.String())
+
 	return value
 }
+
+// lookupEnv follows the convention from the official docker images where FOO_FILE points to a file containing the value of FOO, source tells which one was used
+func lookupEnv(key string) (value string, source string) {
+	value = os.Getenv(key)
+	source = ConfigSourceEnvironment.String()
+
+	if len(value) == 0 {
+		path := os.Getenv(key + "_FILE")
+		if len(path) > 0 {
+			value = readFileAsString(path)
+			source = ConfigSourceFile.String()
+		}
+	}
+
+	return
+}
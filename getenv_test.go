@@ -1,6 +1,7 @@
 package utils_test
 
 import (
+	"io/ioutil"
 	"os"
 	"testing"
 
@@ -29,3 +30,33 @@ func TestGetEnvIntWithSetValueWithDefault(test *testing.T) {
 	value := utils.GetEnvInt("ANOTHER_INT_ENVIRONMENT_VARIABLE", 10000)
 	assert.Equal(test, 4000, value)
 }
+
+func TestGetEnvFromFileConvention(test *testing.T) {
+	err := ioutil.WriteFile("/tmp/a-file-environment-variable", []byte("this is from a file\n"), 0600)
+	assert.NoError(test, err)
+	defer os.Remove("/tmp/a-file-environment-variable")
+
+	os.Setenv("A_FILE_ENVIRONMENT_VARIABLE_FILE", "/tmp/a-file-environment-variable")
+	defer os.Unsetenv("A_FILE_ENVIRONMENT_VARIABLE_FILE")
+
+	value := utils.GetEnv("A_FILE_ENVIRONMENT_VARIABLE", "this is default")
+	assert.Equal(test, "this is from a file", value)
+
+	os.Setenv("A_FILE_ENVIRONMENT_VARIABLE", "this is a value")
+	defer os.Unsetenv("A_FILE_ENVIRONMENT_VARIABLE")
+
+	value = utils.GetEnv("A_FILE_ENVIRONMENT_VARIABLE", "this is default")
+	assert.Equal(test, "this is a value", value)
+}
+
+func TestGetEnvIntFromFileConvention(test *testing.T) {
+	err := ioutil.WriteFile("/tmp/an-int-file-environment-variable", []byte("5000"), 0600)
+	assert.NoError(test, err)
+	defer os.Remove("/tmp/an-int-file-environment-variable")
+
+	os.Setenv("AN_INT_FILE_ENVIRONMENT_VARIABLE_FILE", "/tmp/an-int-file-environment-variable")
+	defer os.Unsetenv("AN_INT_FILE_ENVIRONMENT_VARIABLE_FILE")
+
+	value := utils.GetEnvInt("AN_INT_FILE_ENVIRONMENT_VARIABLE", 10000)
+	assert.Equal(test, 5000, value)
+}
@@ -0,0 +1,148 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"time"
+)
+
+// GetEnvIntStrict returns an environment variable as integer or a default value if it is not set, an error is returned if the value can not be parsed
+func GetEnvIntStrict(key string, fallback int) (value int, err error) {
+	value = fallback
+	err = parseEnv(key, &value)
+	return
+}
+
+// GetEnvInt64Strict returns an environment variable as int64 or a default value if it is not set, an error is returned if the value can not be parsed
+func GetEnvInt64Strict(key string, fallback int64) (value int64, err error) {
+	value = fallback
+	err = parseEnv(key, &value)
+	return
+}
+
+// GetEnvBoolStrict returns an environment variable as boolean or a default value if it is not set, an error is returned if the value can not be parsed
+func GetEnvBoolStrict(key string, fallback bool) (value bool, err error) {
+	value = fallback
+	err = parseEnv(key, &value)
+	return
+}
+
+// GetEnvFloatStrict returns an environment variable as float64 or a default value if it is not set, an error is returned if the value can not be parsed
+func GetEnvFloatStrict(key string, fallback float64) (value float64, err error) {
+	value = fallback
+	err = parseEnv(key, &value)
+	return
+}
+
+// GetEnvDurationStrict returns an environment variable such as 1m30s as time.Duration or a default value if it is not set, an error is returned if the value can not be parsed
+func GetEnvDurationStrict(key string, fallback time.Duration) (value time.Duration, err error) {
+	value = fallback
+	err = parseEnv(key, &value)
+	return
+}
+
+// GetEnvListStrict returns a comma separated environment variable as a slice or a default value if it is not set
+func GetEnvListStrict(key string, fallback []string) (value []string, err error) {
+	value = fallback
+	err = parseEnv(key, &value)
+	return
+}
+
+// GetEnvMapStrict returns an environment variable such as a=1,b=2 as a map or a default value if it is not set, an error is returned if a pair is missing the = sign
+func GetEnvMapStrict(key string, fallback map[string]string) (value map[string]string, err error) {
+	value = fallback
+	err = parseEnv(key, &value)
+	return
+}
+
+// GetEnvURLStrict returns an environment variable as URL or a default value if it is not set, an error is returned if the value can not be parsed
+func GetEnvURLStrict(key string, fallback *url.URL) (value *url.URL, err error) {
+	value = fallback
+	err = parseEnv(key, &value)
+	return
+}
+
+// MustGetEnvInt is like GetEnvIntStrict but panics if the value can not be parsed, it is intended to be used during startup
+func MustGetEnvInt(key string, fallback int) int {
+	value, err := GetEnvIntStrict(key, fallback)
+	panicIfError(err)
+	return value
+}
+
+// MustGetEnvInt64 is like GetEnvInt64Strict but panics if the value can not be parsed, it is intended to be used during startup
+func MustGetEnvInt64(key string, fallback int64) int64 {
+	value, err := GetEnvInt64Strict(key, fallback)
+	panicIfError(err)
+	return value
+}
+
+// MustGetEnvBool is like GetEnvBoolStrict but panics if the value can not be parsed, it is intended to be used during startup
+func MustGetEnvBool(key string, fallback bool) bool {
+	value, err := GetEnvBoolStrict(key, fallback)
+	panicIfError(err)
+	return value
+}
+
+// MustGetEnvFloat is like GetEnvFloatStrict but panics if the value can not be parsed, it is intended to be used during startup
+func MustGetEnvFloat(key string, fallback float64) float64 {
+	value, err := GetEnvFloatStrict(key, fallback)
+	panicIfError(err)
+	return value
+}
+
+// MustGetEnvDuration is like GetEnvDurationStrict but panics if the value can not be parsed, it is intended to be used during startup
+func MustGetEnvDuration(key string, fallback time.Duration) time.Duration {
+	value, err := GetEnvDurationStrict(key, fallback)
+	panicIfError(err)
+	return value
+}
+
+// MustGetEnvList is like GetEnvListStrict but panics if the value can not be parsed, it is intended to be used during startup
+func MustGetEnvList(key string, fallback []string) []string {
+	value, err := GetEnvListStrict(key, fallback)
+	panicIfError(err)
+	return value
+}
+
+// MustGetEnvMap is like GetEnvMapStrict but panics if the value can not be parsed, it is intended to be used during startup
+func MustGetEnvMap(key string, fallback map[string]string) map[string]string {
+	value, err := GetEnvMapStrict(key, fallback)
+	panicIfError(err)
+	return value
+}
+
+// MustGetEnvURL is like GetEnvURLStrict but panics if the value can not be parsed, it is intended to be used during startup
+func MustGetEnvURL(key string, fallback *url.URL) *url.URL {
+	value, err := GetEnvURLStrict(key, fallback)
+	panicIfError(err)
+	return value
+}
+
+func parseEnv(key string, target interface{}) (err error) {
+	input, source := lookupEnv(key)
+	if input == "" {
+		recordConfigValue(key, fmt.Sprint(reflect.ValueOf(target).Elem().Interface()), ConfigSourceDefault.String(), false)
+		return
+	}
+
+	recordConfigValue(key, input, source, source == ConfigSourceFile.String())
+
+	parsed := reflect.New(reflect.TypeOf(target).Elem()).Elem()
+	err = setConfigValue(parsed, input)
+	if err != nil {
+		err = errors.New("Failed to read environment variable " + key + ": " + err.Error())
+		return
+	}
+
+	reflect.ValueOf(target).Elem().Set(parsed)
+
+	return
+}
+
+func panicIfError(err error) {
+	if err != nil {
+		panic(err.Error())
+	}
+}
@@ -0,0 +1,100 @@
+package utils_test
+
+import (
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mojlighetsministeriet/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetEnvIntStrictWithNegativeValue(test *testing.T) {
+	os.Setenv("STRICT_INT_ENVIRONMENT_VARIABLE", "-5")
+	defer os.Unsetenv("STRICT_INT_ENVIRONMENT_VARIABLE")
+
+	value, err := utils.GetEnvIntStrict("STRICT_INT_ENVIRONMENT_VARIABLE", 10)
+	assert.NoError(test, err)
+	assert.Equal(test, -5, value)
+}
+
+func TestGetEnvIntStrictWithMissingValueButWithDefault(test *testing.T) {
+	value, err := utils.GetEnvIntStrict("A_MISSING_STRICT_INT_ENVIRONMENT_VARIABLE", 10)
+	assert.NoError(test, err)
+	assert.Equal(test, 10, value)
+}
+
+func TestFailGetEnvIntStrictWithGarbage(test *testing.T) {
+	os.Setenv("STRICT_BAD_INT_ENVIRONMENT_VARIABLE", "8080abc")
+	defer os.Unsetenv("STRICT_BAD_INT_ENVIRONMENT_VARIABLE")
+
+	value, err := utils.GetEnvIntStrict("STRICT_BAD_INT_ENVIRONMENT_VARIABLE", 10)
+	assert.Error(test, err)
+	assert.Equal(test, "Failed to read environment variable STRICT_BAD_INT_ENVIRONMENT_VARIABLE: could not parse \"8080abc\" as int", err.Error())
+	assert.Equal(test, 10, value)
+
+	os.Setenv("STRICT_BAD_INT_ENVIRONMENT_VARIABLE", "1e3")
+	_, err = utils.GetEnvInt64Strict("STRICT_BAD_INT_ENVIRONMENT_VARIABLE", 10)
+	assert.Error(test, err)
+}
+
+func TestGetEnvBoolAndFloatAndDurationStrict(test *testing.T) {
+	os.Setenv("STRICT_BOOL_ENVIRONMENT_VARIABLE", "true")
+	os.Setenv("STRICT_FLOAT_ENVIRONMENT_VARIABLE", "-1.5e3")
+	os.Setenv("STRICT_DURATION_ENVIRONMENT_VARIABLE", "1h30m")
+	defer os.Unsetenv("STRICT_BOOL_ENVIRONMENT_VARIABLE")
+	defer os.Unsetenv("STRICT_FLOAT_ENVIRONMENT_VARIABLE")
+	defer os.Unsetenv("STRICT_DURATION_ENVIRONMENT_VARIABLE")
+
+	boolValue, err := utils.GetEnvBoolStrict("STRICT_BOOL_ENVIRONMENT_VARIABLE", false)
+	assert.NoError(test, err)
+	assert.Equal(test, true, boolValue)
+
+	floatValue, err := utils.GetEnvFloatStrict("STRICT_FLOAT_ENVIRONMENT_VARIABLE", 0)
+	assert.NoError(test, err)
+	assert.Equal(test, -1500.0, floatValue)
+
+	durationValue, err := utils.GetEnvDurationStrict("STRICT_DURATION_ENVIRONMENT_VARIABLE", time.Second)
+	assert.NoError(test, err)
+	assert.Equal(test, 90*time.Minute, durationValue)
+}
+
+func TestGetEnvListAndMapAndURLStrict(test *testing.T) {
+	os.Setenv("STRICT_LIST_ENVIRONMENT_VARIABLE", "a, b,c")
+	os.Setenv("STRICT_MAP_ENVIRONMENT_VARIABLE", "a=1, b=2")
+	os.Setenv("STRICT_URL_ENVIRONMENT_VARIABLE", "https://internt.mojlighetsministeriet.se/api")
+	defer os.Unsetenv("STRICT_LIST_ENVIRONMENT_VARIABLE")
+	defer os.Unsetenv("STRICT_MAP_ENVIRONMENT_VARIABLE")
+	defer os.Unsetenv("STRICT_URL_ENVIRONMENT_VARIABLE")
+
+	list, err := utils.GetEnvListStrict("STRICT_LIST_ENVIRONMENT_VARIABLE", nil)
+	assert.NoError(test, err)
+	assert.Equal(test, []string{"a", "b", "c"}, list)
+
+	values, err := utils.GetEnvMapStrict("STRICT_MAP_ENVIRONMENT_VARIABLE", nil)
+	assert.NoError(test, err)
+	assert.Equal(test, map[string]string{"a": "1", "b": "2"}, values)
+
+	parsedURL, err := utils.GetEnvURLStrict("STRICT_URL_ENVIRONMENT_VARIABLE", nil)
+	assert.NoError(test, err)
+	assert.Equal(test, &url.URL{Scheme: "https", Host: "internt.mojlighetsministeriet.se", Path: "/api"}, parsedURL)
+}
+
+func TestFailGetEnvMapStrictWithMissingEqualSign(test *testing.T) {
+	os.Setenv("STRICT_BAD_MAP_ENVIRONMENT_VARIABLE", "a=1,b")
+	defer os.Unsetenv("STRICT_BAD_MAP_ENVIRONMENT_VARIABLE")
+
+	_, err := utils.GetEnvMapStrict("STRICT_BAD_MAP_ENVIRONMENT_VARIABLE", nil)
+	assert.Error(test, err)
+}
+
+func TestMustGetEnvIntPanicsWithGarbage(test *testing.T) {
+	os.Setenv("MUST_BAD_INT_ENVIRONMENT_VARIABLE", "abc")
+	defer os.Unsetenv("MUST_BAD_INT_ENVIRONMENT_VARIABLE")
+
+	assert.PanicsWithValue(test, "Failed to read environment variable MUST_BAD_INT_ENVIRONMENT_VARIABLE: could not parse \"abc\" as int", func() {
+		utils.MustGetEnvInt("MUST_BAD_INT_ENVIRONMENT_VARIABLE", 10)
+	})
+	assert.Equal(test, 10, utils.MustGetEnvInt("A_MISSING_MUST_INT_ENVIRONMENT_VARIABLE", 10))
+}
@@ -9,26 +9,41 @@ import (
 
 // GetFileAsString returns file contents or a default value
 func GetFileAsString(path, fallback string) string {
-	content, err := ioutil.ReadFile(path)
+	value := readFileAsString(path)
+	source := ConfigSourceFile.String()
 
-	if len(content) == 0 || err != nil {
-		return fallback
+	if len(value) == 0 {
+		value = fallback
+		source = ConfigSourceDefault.String()
 	}
 
-	return strings.Trim(string(content), "\n ")
+	recordConfigValue(path, value, source, true)
+
+	return value
 }
 
 // GetFileAsInt returns file contents or a default value as integer
 func GetFileAsInt(path string, fallback int) int {
-	content, err := ioutil.ReadFile(path)
-
-	contentString := strings.Trim(string(content), "\n ")
-	if len(contentString) == 0 || err != nil {
+	contentString := readFileAsString(path)
+	if len(contentString) == 0 {
+		recordConfigValue(path, strconv.Itoa(fallback), ConfigSourceDefault.String(), true)
 		return fallback
 	}
 
 	pattern := regexp.MustCompile("[^\\d]+")
 	value, _ := strconv.Atoi(pattern.ReplaceAllString(contentString, ""))
 
+	recordConfigValue(path, strconv.Itoa(value), ConfigSourceFile.String(), true)
+
 	return value
 }
+
+func readFileAsString(path string) string {
+	content, err := ioutil.ReadFile(path)
+
+	if len(content) == 0 || err != nil {
+		return ""
+	}
+
+	return strings.Trim(string(content), "\n ")
+}
@@ -1,9 +1,179 @@
 package utils
 
-import "github.com/labstack/echo"
+import (
+	"net"
+	"net/http"
+	"strings"
 
-// GetOriginalSystemURLFromContext will take a context and return the systems base URL (the URL to the actual external host) e.g. https://internt.mojlighetsministeriet.se
+	"github.com/labstack/echo"
+)
+
+// GetOriginalSystemURLFromContext will take a context and return the systems base URL (the URL to the actual external host) e.g. https://internt.mojlighetsministeriet.se.
+// See GetOriginalSystemURLFromRequest for how the URL is found.
 func GetOriginalSystemURLFromContext(context echo.Context) string {
-	headers := context.Request().Header
-	return headers.Get("X-Forwarded-Proto") + "://" + headers.Get("X-Forwarded-Host")
+	return GetOriginalSystemURLFromRequest(context.Request())
+}
+
+// GetOriginalSystemURLFromRequest returns the systems base URL for a request. If the request comes from a trusted proxy (see SetTrustedProxies) the scheme and host are
+// read from the RFC 7239 Forwarded header, then X-Forwarded-Proto and X-Forwarded-Host and last from the request itself. When a header has passed several proxies the
+// values are read from right to left, skipping the ones added by trusted proxies the same way as GetClientIP, so a client can not prepend its own host.
+// X-Forwarded-Port is added to the host unless it is the default port and X-Forwarded-Prefix to the path.
+// Requests from other clients only use the request itself so they can not make the system generate links to another domain.
+func GetOriginalSystemURLFromRequest(request *http.Request) string {
+	scheme := "http"
+	if request.TLS != nil {
+		scheme = "https"
+	}
+
+	if !IsTrustedProxy(request.RemoteAddr) {
+		return scheme + "://" + request.Host
+	}
+
+	headers := request.Header
+	forwarded := selectForwardedElement(headers)
+	hops := getTrustedHops(getXForwardedForAddresses(headers))
+
+	scheme = strings.ToLower(firstNonEmpty(forwarded["proto"], selectHeaderValue(headers, "X-Forwarded-Proto", hops), scheme))
+	host := firstNonEmpty(forwarded["host"], selectHeaderValue(headers, "X-Forwarded-Host", hops), request.Host)
+
+	port := selectHeaderValue(headers, "X-Forwarded-Port", hops)
+	if port != "" && !hasPort(host) && !isDefaultPort(scheme, port) {
+		host = net.JoinHostPort(strings.Trim(host, "[]"), port)
+	}
+
+	return scheme + "://" + host + normalizePrefix(selectHeaderValue(headers, "X-Forwarded-Prefix", hops))
+}
+
+// selectForwardedElement returns the parameters of the Forwarded element added by the trusted proxy closest to the client. The elements are read from right to left
+// and the ones for trusted proxies are skipped, if every element is for a trusted proxy the first one is used.
+func selectForwardedElement(headers http.Header) (parameters map[string]string) {
+	elements := splitOutsideQuotes(strings.Join(headers["Forwarded"], ","), ',')
+
+	for index := len(elements) - 1; index >= 0; index-- {
+		parameters = parseForwardedElement(elements[index])
+		if address := parameters["for"]; address == "" || !IsTrustedProxy(stripPort(address)) {
+			return
+		}
+	}
+
+	return
+}
+
+// parseForwardedElement returns the parameters of an element of a Forwarded header such as for=192.0.2.60;proto=https;host=example.com
+func parseForwardedElement(element string) (parameters map[string]string) {
+	parameters = make(map[string]string)
+
+	for _, pair := range splitOutsideQuotes(element, ';') {
+		keyValue := strings.SplitN(pair, "=", 2)
+		if len(keyValue) != 2 {
+			continue
+		}
+
+		parameters[strings.ToLower(strings.TrimSpace(keyValue[0]))] = unquoteForwardedValue(strings.TrimSpace(keyValue[1]))
+	}
+
+	return
+}
+
+// selectHeaderValue returns the value of a comma separated X-Forwarded-* header that was added by the trusted proxy closest to the client, hops is the number of
+// trusted proxies after it (see getTrustedHops). Headers with fewer values than that are read from the start.
+func selectHeaderValue(headers http.Header, key string, hops int) string {
+	values := splitHeaderValues(headers, key)
+	if len(values) == 0 {
+		return ""
+	}
+
+	index := len(values) - 1 - hops
+	if index < 0 {
+		index = 0
+	}
+
+	return values[index]
+}
+
+// splitHeaderValues returns the non empty values of a comma separated header that may be sent on several lines
+func splitHeaderValues(headers http.Header, key string) (values []string) {
+	for _, value := range strings.Split(strings.Join(headers[http.CanonicalHeaderKey(key)], ","), ",") {
+		value = strings.TrimSpace(value)
+		if value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return
+}
+
+func splitOutsideQuotes(input string, separator rune) (parts []string) {
+	inQuotes := false
+	escaped := false
+	start := 0
+
+	for index, character := range input {
+		switch {
+		case escaped:
+			escaped = false
+		case character == '\\' && inQuotes:
+			escaped = true
+		case character == '"':
+			inQuotes = !inQuotes
+		case character == separator && !inQuotes:
+			parts = append(parts, input[start:index])
+			start = index + 1
+		}
+	}
+
+	parts = append(parts, input[start:])
+
+	return
+}
+
+func unquoteForwardedValue(value string) string {
+	if len(value) < 2 || !strings.HasPrefix(value, "\"") || !strings.HasSuffix(value, "\"") {
+		return value
+	}
+
+	value = value[1 : len(value)-1]
+
+	var unquoted strings.Builder
+	escaped := false
+
+	for _, character := range value {
+		if character == '\\' && !escaped {
+			escaped = true
+			continue
+		}
+
+		escaped = false
+		unquoted.WriteRune(character)
+	}
+
+	return unquoted.String()
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+
+	return ""
+}
+
+func hasPort(host string) bool {
+	_, _, err := net.SplitHostPort(host)
+	return err == nil
+}
+
+func isDefaultPort(scheme string, port string) bool {
+	return (scheme == "https" && port == "443") || (scheme == "http" && port == "80")
+}
+
+func normalizePrefix(prefix string) string {
+	prefix = strings.TrimRight(prefix, "/")
+	if prefix != "" && !strings.HasPrefix(prefix, "/") {
+		prefix = "/" + prefix
+	}
+
+	return prefix
 }
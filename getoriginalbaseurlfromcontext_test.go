@@ -10,11 +10,15 @@ import (
 )
 
 func TestGetOriginalSystemURLFromContext(test *testing.T) {
+	SetTrustedProxies(append(DefaultTrustedProxies, PrivateNetworks...)...)
+	defer SetTrustedProxies(DefaultTrustedProxies...)
+
 	service := echo.New()
 	request := httptest.NewRequest(echo.GET, "/", strings.NewReader("{}"))
 	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 	request.Header.Set("X-Forwarded-Proto", "https")
 	request.Header.Set("X-Forwarded-Host", "internt.mojlighetsministeriet.se")
+	request.RemoteAddr = "10.0.0.1:1234"
 	recorder := httptest.NewRecorder()
 	context := service.NewContext(request, recorder)
 
@@ -22,3 +26,96 @@ func TestGetOriginalSystemURLFromContext(test *testing.T) {
 
 	assert.Equal(test, "https://internt.mojlighetsministeriet.se", url)
 }
+
+func getOriginalSystemURL(headers map[string]string) string {
+	SetTrustedProxies(append(DefaultTrustedProxies, PrivateNetworks...)...)
+	defer SetTrustedProxies(DefaultTrustedProxies...)
+
+	service := echo.New()
+	request := httptest.NewRequest(echo.GET, "/", nil)
+	request.RemoteAddr = "10.0.0.1:1234"
+	for key, value := range headers {
+		request.Header.Set(key, value)
+	}
+
+	return GetOriginalSystemURLFromContext(service.NewContext(request, httptest.NewRecorder()))
+}
+
+func TestGetOriginalSystemURLFromContextWithForwardedHeader(test *testing.T) {
+	assert.Equal(test, "https://internt.mojlighetsministeriet.se", getOriginalSystemURL(map[string]string{
+		"Forwarded": "for=192.0.2.60;proto=https;host=internt.mojlighetsministeriet.se, for=10.0.0.1;proto=http;host=internal",
+	}))
+
+	assert.Equal(test, "https://internt.mojlighetsministeriet.se:8443", getOriginalSystemURL(map[string]string{
+		"Forwarded":         "For=\"[2001:db8:cafe::17]:4711\";Proto=HTTPS;Host=\"internt.mojlighetsministeriet.se:8443\"",
+		"X-Forwarded-Proto": "http",
+		"X-Forwarded-Host":  "internal",
+	}))
+
+	assert.Equal(test, "https://internt.mojlighetsministeriet.se", getOriginalSystemURL(map[string]string{
+		"Forwarded":        "for=192.0.2.60;proto=https",
+		"X-Forwarded-Host": "internt.mojlighetsministeriet.se",
+	}))
+}
+
+func TestGetOriginalSystemURLFromContextWithMultipleHops(test *testing.T) {
+	assert.Equal(test, "https://internt.mojlighetsministeriet.se", getOriginalSystemURL(map[string]string{
+		"X-Forwarded-For":   "203.0.113.9, 10.0.0.5",
+		"X-Forwarded-Proto": "https, http",
+		"X-Forwarded-Host":  "internt.mojlighetsministeriet.se, internal",
+	}))
+}
+
+func TestGetOriginalSystemURLFromContextWithValuesPrependedByClient(test *testing.T) {
+	assert.Equal(test, "https://internt.mojlighetsministeriet.se", getOriginalSystemURL(map[string]string{
+		"Forwarded": "host=attacker.example.com;proto=http, for=203.0.113.9;proto=https;host=internt.mojlighetsministeriet.se",
+	}))
+
+	assert.Equal(test, "https://internt.mojlighetsministeriet.se", getOriginalSystemURL(map[string]string{
+		"X-Forwarded-Proto": "http, https",
+		"X-Forwarded-Host":  "attacker.example.com, internt.mojlighetsministeriet.se",
+	}))
+
+	assert.Equal(test, "https://internt.mojlighetsministeriet.se", getOriginalSystemURL(map[string]string{
+		"X-Forwarded-For":   "198.51.100.1, 203.0.113.9, 10.0.0.5",
+		"X-Forwarded-Proto": "http, https, http",
+		"X-Forwarded-Host":  "attacker.example.com, internt.mojlighetsministeriet.se, internal",
+	}))
+}
+
+func TestGetOriginalSystemURLFromContextWithPortAndPrefix(test *testing.T) {
+	assert.Equal(test, "https://internt.mojlighetsministeriet.se:8443/account", getOriginalSystemURL(map[string]string{
+		"X-Forwarded-Proto":  "https",
+		"X-Forwarded-Host":   "internt.mojlighetsministeriet.se",
+		"X-Forwarded-Port":   "8443",
+		"X-Forwarded-Prefix": "account/",
+	}))
+
+	assert.Equal(test, "https://internt.mojlighetsministeriet.se/account", getOriginalSystemURL(map[string]string{
+		"X-Forwarded-Proto":  "https",
+		"X-Forwarded-Host":   "internt.mojlighetsministeriet.se",
+		"X-Forwarded-Port":   "443",
+		"X-Forwarded-Prefix": "/account",
+	}))
+
+	assert.Equal(test, "http://[2001:db8::1]:8080", getOriginalSystemURL(map[string]string{
+		"X-Forwarded-Host": "[2001:db8::1]",
+		"X-Forwarded-Port": "8080",
+	}))
+}
+
+func TestGetOriginalSystemURLFromContextWithoutHeaders(test *testing.T) {
+	assert.Equal(test, "http://example.com", getOriginalSystemURL(nil))
+}
+
+func TestGetOriginalSystemURLFromContextWithUntrustedProxy(test *testing.T) {
+	service := echo.New()
+	request := httptest.NewRequest(echo.GET, "/", nil)
+	request.Header.Set("X-Forwarded-Proto", "https")
+	request.Header.Set("X-Forwarded-Host", "attacker.example.com")
+	request.Header.Set("Forwarded", "host=attacker.example.com")
+
+	url := GetOriginalSystemURLFromContext(service.NewContext(request, httptest.NewRecorder()))
+
+	assert.Equal(test, "http://example.com", url)
+}
@@ -2,6 +2,7 @@ package httprequest
 
 import (
 	"bytes"
+	"crypto/tls"
 	"encoding/json"
 	"io/ioutil"
 	"net"
@@ -29,22 +30,30 @@ func NewClientWithCustomTimeout(millisecondTimeout time.Duration) (client *Clien
 		return
 	}
 
-	transport := &http.Transport{
-		Dial: (&net.Dialer{
-			Timeout: 5 * time.Second,
-		}).Dial,
-		TLSHandshakeTimeout: 5 * time.Second,
-		TLSClientConfig:     tlsConfig,
-	}
+	client = NewClientWithTLSConfig(millisecondTimeout, tlsConfig)
 
-	client = &Client{
+	return
+}
+
+// NewClientWithTLSConfig creates a http client with a custom TLS config e.g. from utils.GetClientCertificateTLSConfig for mutual TLS, the timeout is in milliseconds.
+// The default TLS profile (see utils.SetDefaultTLSProfile) is applied to a copy of the config.
+func NewClientWithTLSConfig(millisecondTimeout time.Duration, tlsConfig *tls.Config) *Client {
+	return &Client{
 		http.Client{
 			Timeout:   time.Millisecond * millisecondTimeout,
-			Transport: transport,
+			Transport: newTransport(tlsConfig),
 		},
 	}
+}
 
-	return
+func newTransport(tlsConfig *tls.Config) *http.Transport {
+	return &http.Transport{
+		Dial: (&net.Dialer{
+			Timeout: 5 * time.Second,
+		}).Dial,
+		TLSHandshakeTimeout: 5 * time.Second,
+		TLSClientConfig:     utils.ApplyDefaultTLSProfile(tlsConfig),
+	}
 }
 
 func (client *Client) sendRequest(request *http.Request) (responseBody []byte, err error) {
@@ -198,20 +207,18 @@ func NewJSONClientWithCustomTimeout(millisecondTimeout time.Duration) (client *J
 		return
 	}
 
-	transport := &http.Transport{
-		Dial: (&net.Dialer{
-			Timeout: 5 * time.Second,
-		}).Dial,
-		TLSHandshakeTimeout: 5 * time.Second,
-		TLSClientConfig:     tlsConfig,
-	}
+	client = NewJSONClientWithTLSConfig(millisecondTimeout, tlsConfig)
+
+	return
+}
 
-	client = &JSONClient{
+// NewJSONClientWithTLSConfig creates a http client for JSON requests with a custom TLS config e.g. from utils.GetClientCertificateTLSConfig for mutual TLS, the timeout is in milliseconds.
+// The default TLS profile (see utils.SetDefaultTLSProfile) is applied to a copy of the config.
+func NewJSONClientWithTLSConfig(millisecondTimeout time.Duration, tlsConfig *tls.Config) *JSONClient {
+	return &JSONClient{
 		http.Client{
 			Timeout:   time.Millisecond * millisecondTimeout,
-			Transport: transport,
+			Transport: newTransport(tlsConfig),
 		},
 	}
-
-	return
 }
@@ -1,11 +1,15 @@
 package httprequest
 
 import (
+	"crypto/tls"
 	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/labstack/echo"
+	"github.com/mojlighetsministeriet/utils/tlstest"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -188,3 +192,50 @@ func TestFailJSONClientGetWithInvalidDomainName(test *testing.T) {
 	assert.Equal(test, 0, response.ID)
 	assert.Equal(test, "", response.Name)
 }
+
+func TestNewClientsWithTLSConfig(test *testing.T) {
+	tlsConfig := &tls.Config{ServerName: "internal.example.com"}
+
+	client := NewClientWithTLSConfig(2000, tlsConfig)
+	assert.Equal(test, 2*time.Second, client.Timeout)
+	assert.Equal(test, "internal.example.com", client.Transport.(*http.Transport).TLSClientConfig.ServerName)
+	assert.Equal(test, uint16(tls.VersionTLS12), client.Transport.(*http.Transport).TLSClientConfig.MinVersion)
+
+	jsonClient := NewJSONClientWithTLSConfig(2000, tlsConfig)
+	assert.Equal(test, 2*time.Second, jsonClient.Timeout)
+	assert.Equal(test, "internal.example.com", jsonClient.Transport.(*http.Transport).TLSClientConfig.ServerName)
+	assert.Equal(test, uint16(tls.VersionTLS12), jsonClient.Transport.(*http.Transport).TLSClientConfig.MinVersion)
+	assert.Equal(test, uint16(0), tlsConfig.MinVersion)
+}
+
+func TestJSONClientGetOverTLS(test *testing.T) {
+	authority, err := tlstest.NewCertificateAuthority()
+	assert.NoError(test, err)
+
+	certificate, err := authority.IssueServerCertificate("127.0.0.1")
+	assert.NoError(test, err)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "application/json")
+		writer.Write([]byte("{\"id\":103898330,\"name\":\"utils\"}"))
+	}))
+	server.TLS = certificate.ServerTLSConfig()
+	server.StartTLS()
+	defer server.Close()
+
+	type Response struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+
+	response := Response{}
+	err = NewJSONClientWithTLSConfig(2000, authority.ClientTLSConfig()).Get(server.URL, &response)
+	assert.NoError(test, err)
+	assert.Equal(test, 103898330, response.ID)
+
+	otherAuthority, err := tlstest.NewCertificateAuthority()
+	assert.NoError(test, err)
+
+	err = NewJSONClientWithTLSConfig(2000, otherAuthority.ClientTLSConfig()).Get(server.URL, &response)
+	assert.Error(test, err)
+}
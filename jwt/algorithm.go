@@ -0,0 +1,212 @@
+package jwt
+
+import (
+	gocrypto "crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	_ "crypto/sha256" // Registers SHA-256 for crypto.Hash
+	_ "crypto/sha512" // Registers SHA-384 for crypto.Hash
+	"encoding/asn1"
+	"errors"
+	"math/big"
+
+	"github.com/SermoDigital/jose/crypto"
+	"github.com/SermoDigital/jose/jws"
+)
+
+// Algorithm is the name of a JWS signing algorithm as used in the alg header of a token
+type Algorithm string
+
+const (
+	// RS256 is RSASSA-PKCS1-v1_5 with SHA-256, it is used by default for RSA keys
+	RS256 Algorithm = "RS256"
+	// PS256 is RSASSA-PSS with SHA-256
+	PS256 Algorithm = "PS256"
+	// ES256 is ECDSA with the P-256 curve and SHA-256
+	ES256 Algorithm = "ES256"
+	// ES384 is ECDSA with the P-384 curve and SHA-384
+	ES384 Algorithm = "ES384"
+	// EdDSA is Ed25519
+	EdDSA Algorithm = "EdDSA"
+)
+
+var signingMethods = map[Algorithm]*signingMethod{
+	RS256: {algorithm: RS256, hash: gocrypto.SHA256},
+	PS256: {algorithm: PS256, hash: gocrypto.SHA256},
+	ES256: {algorithm: ES256, hash: gocrypto.SHA256, curve: elliptic.P256()},
+	ES384: {algorithm: ES384, hash: gocrypto.SHA384, curve: elliptic.P384()},
+	EdDSA: {algorithm: EdDSA},
+}
+
+func init() {
+	// jose has no Ed25519 support, the other algorithms are already registered by jose
+	jws.RegisterSigningMethod(signingMethods[EdDSA])
+}
+
+// DefaultAlgorithm returns the algorithm used for a key when none is chosen, RS256 for RSA, ES256 or ES384 for ECDSA depending on the curve and EdDSA for Ed25519
+func DefaultAlgorithm(publicKey gocrypto.PublicKey) (algorithm Algorithm, err error) {
+	algorithms, err := algorithmsForKey(publicKey)
+	if err != nil {
+		return
+	}
+
+	algorithm = algorithms[0]
+
+	return
+}
+
+// algorithmsForKey returns the algorithms that can be used with a key, the first one is the default
+func algorithmsForKey(publicKey gocrypto.PublicKey) (algorithms []Algorithm, err error) {
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		algorithms = []Algorithm{RS256, PS256}
+	case *ecdsa.PublicKey:
+		switch key.Curve {
+		case elliptic.P256():
+			algorithms = []Algorithm{ES256}
+		case elliptic.P384():
+			algorithms = []Algorithm{ES384}
+		default:
+			err = errors.New("Unsupported elliptic curve " + key.Curve.Params().Name)
+		}
+	case ed25519.PublicKey:
+		algorithms = []Algorithm{EdDSA}
+	default:
+		err = errors.New("Unsupported key type")
+	}
+
+	return
+}
+
+func getSigningMethod(algorithm Algorithm) (method *signingMethod, err error) {
+	method, found := signingMethods[algorithm]
+	if !found {
+		err = errors.New("Unsupported algorithm " + string(algorithm))
+	}
+
+	return
+}
+
+// signingMethod implements crypto.SigningMethod from jose for any crypto.Signer, such as a key kept in a hardware security module, instead of only concrete private key types
+type signingMethod struct {
+	algorithm Algorithm
+	hash      gocrypto.Hash
+	curve     elliptic.Curve
+}
+
+func (method *signingMethod) Alg() string {
+	return string(method.algorithm)
+}
+
+func (method *signingMethod) Hasher() gocrypto.Hash {
+	return method.hash
+}
+
+func (method *signingMethod) Sign(data []byte, key interface{}) (signature crypto.Signature, err error) {
+	signer, ok := key.(gocrypto.Signer)
+	if !ok {
+		err = crypto.ErrInvalidKey
+		return
+	}
+
+	err = method.checkKey(signer.Public())
+	if err != nil {
+		return
+	}
+
+	if method.algorithm == EdDSA {
+		signature, err = signer.Sign(rand.Reader, data, gocrypto.Hash(0))
+		return
+	}
+
+	hasher := method.hash.New()
+	hasher.Write(data)
+	digest := hasher.Sum(nil)
+
+	var options gocrypto.SignerOpts = method.hash
+	if method.algorithm == PS256 {
+		options = &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: method.hash}
+	}
+
+	signature, err = signer.Sign(rand.Reader, digest, options)
+	if err != nil || method.curve == nil {
+		return
+	}
+
+	// crypto.Signer returns ASN.1 encoded ECDSA signatures but JWS uses the fixed size concatenation of r and s
+	var parsed struct{ R, S *big.Int }
+	_, err = asn1.Unmarshal(signature, &parsed)
+	if err != nil {
+		return
+	}
+
+	size := (method.curve.Params().BitSize + 7) / 8
+	signature = make([]byte, 2*size)
+	parsed.R.FillBytes(signature[:size])
+	parsed.S.FillBytes(signature[size:])
+
+	return
+}
+
+func (method *signingMethod) Verify(data []byte, signature crypto.Signature, key interface{}) (err error) {
+	err = method.checkKey(key)
+	if err != nil {
+		return
+	}
+
+	if method.algorithm == EdDSA {
+		if !ed25519.Verify(key.(ed25519.PublicKey), data, signature) {
+			err = crypto.ErrSignatureInvalid
+		}
+		return
+	}
+
+	hasher := method.hash.New()
+	hasher.Write(data)
+	digest := hasher.Sum(nil)
+
+	switch publicKey := key.(type) {
+	case *rsa.PublicKey:
+		if method.algorithm == PS256 {
+			err = rsa.VerifyPSS(publicKey, method.hash, digest, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto, Hash: method.hash})
+		} else {
+			err = rsa.VerifyPKCS1v15(publicKey, method.hash, digest, signature)
+		}
+	case *ecdsa.PublicKey:
+		size := (method.curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			err = crypto.ErrSignatureInvalid
+			return
+		}
+
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(publicKey, digest, r, s) {
+			err = crypto.ErrSignatureInvalid
+		}
+	}
+
+	return
+}
+
+// checkKey makes sure that a public key is of the type the algorithm requires so a token can not be verified with a key meant for another algorithm
+func (method *signingMethod) checkKey(publicKey interface{}) (err error) {
+	algorithms, err := algorithmsForKey(publicKey)
+	if err != nil {
+		err = crypto.ErrInvalidKey
+		return
+	}
+
+	for _, algorithm := range algorithms {
+		if algorithm == method.algorithm {
+			return
+		}
+	}
+
+	err = errors.New("The key can not be used with algorithm " + string(method.algorithm))
+
+	return
+}
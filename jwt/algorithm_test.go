@@ -0,0 +1,148 @@
+package jwt_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"io"
+	"testing"
+	"time"
+
+	josecrypto "github.com/SermoDigital/jose/crypto"
+	"github.com/SermoDigital/jose/jws"
+	"github.com/mojlighetsministeriet/utils/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+// opaqueSigner hides the concrete key type the same way a key in a hardware security module would
+type opaqueSigner struct {
+	signer crypto.Signer
+}
+
+func (signer opaqueSigner) Public() crypto.PublicKey {
+	return signer.signer.Public()
+}
+
+func (signer opaqueSigner) Sign(random io.Reader, digest []byte, options crypto.SignerOpts) ([]byte, error) {
+	return signer.signer.Sign(random, digest, options)
+}
+
+func TestGenerateAndParseIfValidWithAlgorithms(test *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(test, err)
+
+	p256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(test, err)
+
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	assert.NoError(test, err)
+
+	_, ed25519Key, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(test, err)
+
+	account := Account{ID: "an-account", Email: "tech+testing@mojlighetsministerietest.se", Roles: []string{"user"}}
+
+	for _, testCase := range []struct {
+		algorithm  jwt.Algorithm
+		privateKey crypto.Signer
+	}{
+		{jwt.RS256, rsaKey},
+		{jwt.PS256, rsaKey},
+		{jwt.ES256, p256Key},
+		{jwt.ES384, p384Key},
+		{jwt.EdDSA, ed25519Key},
+		{jwt.ES256, opaqueSigner{p256Key}},
+		{jwt.PS256, opaqueSigner{rsaKey}},
+	} {
+		token, err := jwt.GenerateWithAlgorithm("test-service", testCase.algorithm, testCase.privateKey, &account, time.Now().Add(time.Minute))
+		assert.NoError(test, err, string(testCase.algorithm))
+
+		parsedToken, err := jwt.ParseIfValid(testCase.privateKey.Public(), token)
+		assert.NoError(test, err, string(testCase.algorithm))
+		assert.Equal(test, "an-account", parsedToken.Claims().Get("sub"))
+		assert.Equal(test, string(testCase.algorithm), parsedToken.(jws.JWS).Protected().Get("alg"))
+	}
+}
+
+func TestDefaultAlgorithm(test *testing.T) {
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	assert.NoError(test, err)
+
+	algorithm, err := jwt.DefaultAlgorithm(p384Key.Public())
+	assert.NoError(test, err)
+	assert.Equal(test, jwt.ES384, algorithm)
+
+	ed25519Key, _, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(test, err)
+
+	algorithm, err = jwt.DefaultAlgorithm(ed25519Key)
+	assert.NoError(test, err)
+	assert.Equal(test, jwt.EdDSA, algorithm)
+
+	p224Key, err := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+	assert.NoError(test, err)
+
+	_, err = jwt.DefaultAlgorithm(p224Key.Public())
+	assert.Error(test, err)
+	assert.Equal(test, "Unsupported elliptic curve P-224", err.Error())
+}
+
+func TestFailParseIfValidWithAlgorithmThatIsNotAllowed(test *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(test, err)
+
+	account := Account{ID: "an-account"}
+	token, err := jwt.GenerateWithAlgorithm("test-service", jwt.RS256, rsaKey, &account, time.Now().Add(time.Minute))
+	assert.NoError(test, err)
+
+	_, err = jwt.ParseIfValidWithAlgorithms(&rsaKey.PublicKey, token, jwt.PS256)
+	assert.Error(test, err)
+	assert.Equal(test, "The algorithm RS256 is not allowed", err.Error())
+
+	parsedToken, err := jwt.ParseIfValidWithAlgorithms(&rsaKey.PublicKey, token, jwt.PS256, jwt.RS256)
+	assert.NoError(test, err)
+	assert.Equal(test, "an-account", parsedToken.Claims().Get("sub"))
+}
+
+func TestFailParseIfValidWithAlgorithmConfusion(test *testing.T) {
+	p256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(test, err)
+
+	// A token signed with HMAC using the public key as secret must not be accepted
+	claims := jws.Claims{}
+	claims.SetSubject("an-attacker")
+	claims.SetExpiration(time.Now().Add(time.Minute))
+	token, err := jws.NewJWT(claims, josecrypto.SigningMethodHS256).Serialize(elliptic.Marshal(elliptic.P256(), p256Key.X, p256Key.Y))
+	assert.NoError(test, err)
+
+	parsedToken, err := jwt.ParseIfValid(&p256Key.PublicKey, token)
+	assert.Error(test, err)
+	assert.Equal(test, "The algorithm HS256 is not allowed", err.Error())
+	assert.Equal(test, nil, parsedToken.Claims().Get("sub"))
+
+	// A token signed with an RSA key must not be verified with an ECDSA key even if RS256 is allowed
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(test, err)
+
+	token, err = jwt.GenerateWithAlgorithm("test-service", jwt.RS256, rsaKey, &Account{ID: "an-account"}, time.Now().Add(time.Minute))
+	assert.NoError(test, err)
+
+	_, err = jwt.ParseIfValidWithAlgorithms(&p256Key.PublicKey, token, jwt.RS256)
+	assert.Error(test, err)
+}
+
+func TestFailGenerateWithAlgorithmWithWrongKeyType(test *testing.T) {
+	p256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(test, err)
+
+	_, err = jwt.GenerateWithAlgorithm("test-service", jwt.ES384, p256Key, &Account{ID: "an-account"}, time.Now().Add(time.Minute))
+	assert.Error(test, err)
+	assert.Equal(test, "The key can not be used with algorithm ES384", err.Error())
+
+	_, err = jwt.GenerateWithAlgorithm("test-service", jwt.Algorithm("none"), p256Key, &Account{ID: "an-account"}, time.Now().Add(time.Minute))
+	assert.Error(test, err)
+	assert.Equal(test, "Unsupported algorithm none", err.Error())
+}
@@ -2,36 +2,21 @@ package jwt
 
 import (
 	"crypto/rsa"
-	"crypto/x509"
-	"encoding/pem"
 	"errors"
-	"io/ioutil"
 	"net/http"
 )
 
-// FetchPublicKey will fetch a PEM encoded §public RSA key over HTTP and return it's struct
+// FetchPublicKey will fetch a PEM encoded public RSA key over HTTP and return it's struct, use LoadPublicKeyFromURL for other key types
 func FetchPublicKey(url string) (publicKey *rsa.PublicKey, err error) {
-	response, err := http.Get(url)
+	key, err := fetchPublicKey(&http.Client{Timeout: DefaultKeyFetchTimeout}, url)
 	if err != nil {
 		return
 	}
 
-	if response.StatusCode != http.StatusOK {
-		err = errors.New("Failed to fetch public key")
-		return
-	}
-
-	defer response.Body.Close()
-
-	body, _ := ioutil.ReadAll(response.Body)
-	block, _ := pem.Decode(body)
-	if block == nil {
-		err = errors.New("Unable to decode pem")
-		return
+	publicKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		err = errors.New("The public key from " + url + " is not an RSA key")
 	}
 
-	key, _ := x509.ParsePKIXPublicKey(block.Bytes)
-	publicKey = key.(*rsa.PublicKey)
-
 	return
 }
@@ -75,3 +75,39 @@ F4gSVHbWTQuv2ZdqfDjz3JXiO4zM9LmGV/CL2lQ7OuBV/DB5A9SjVCgjO2MI0oL1
 `
 
 const badPublicKeyPEMFixture = `bad pem text, la la la laaaa`
+
+func TestFetchPublicKeyThatIsNotRSA(test *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(
+		"GET",
+		"http://service/public-key",
+		httpmock.NewStringResponder(http.StatusOK, ecdsaPublicKeyPEMFixture),
+	)
+
+	_, err := jwt.FetchPublicKey("http://service/public-key")
+	assert.Error(test, err)
+	assert.Equal(test, "The public key from http://service/public-key is not an RSA key", err.Error())
+}
+
+func TestFetchPublicKeyWithGarbageInPEM(test *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(
+		"GET",
+		"http://service/public-key",
+		httpmock.NewStringResponder(http.StatusOK, "-----BEGIN PUBLIC KEY-----\nZ2FyYmFnZQ==\n-----END PUBLIC KEY-----\n"),
+	)
+
+	_, err := jwt.FetchPublicKey("http://service/public-key")
+	assert.Error(test, err)
+}
+
+const ecdsaPublicKeyPEMFixture = `
+-----BEGIN PUBLIC KEY-----
+MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAEEVs/o5+uQbTjL3chynL4wXgUg2R9
+q9UU8I5mEovUf86QZ7kOBIjJwqnzD1omageEHWwHdBO6B+dFabmdT9POxg==
+-----END PUBLIC KEY-----
+`
@@ -0,0 +1,229 @@
+package jwt
+
+import (
+	gocrypto "crypto"
+	"errors"
+	"sync"
+	"time"
+)
+
+// SigningKey is a private key that an Issuer can sign tokens with, ID is used as kid and defaults to the RFC 7638 thumbprint of the public key
+// and Algorithm defaults to DefaultAlgorithm for the key type
+type SigningKey struct {
+	ID        string
+	Algorithm Algorithm
+	Key       gocrypto.Signer
+}
+
+type issuerKey struct {
+	SigningKey
+	verifyUntil time.Time
+}
+
+// Issuer signs tokens with its active key and stamps the kid of the key into every token. Other keys are kept for verification only, a key that is being
+// introduced until it is promoted and a previously active key until its grace period ends, so rotating the active key does not invalidate tokens already issued.
+// Issuer implements KeySet and VerificationKeyProvider so it can be passed to ParseIfValid, RequiredRoleMiddleware and JWKSHandler.
+type Issuer struct {
+	name     string
+	mutex    sync.RWMutex
+	activeID string
+	keys     []issuerKey
+}
+
+// NewIssuer creates an Issuer that sets the iss claim to name and signs tokens with key
+func NewIssuer(name string, key SigningKey) (issuer *Issuer, err error) {
+	result := &Issuer{name: name}
+
+	err = result.AddKey(key)
+	if err != nil {
+		return
+	}
+
+	result.activeID = result.keys[0].ID
+	issuer = result
+
+	return
+}
+
+// Generate a new token from an account signed with the active key
+func (issuer *Issuer) Generate(account Account) ([]byte, error) {
+	return issuer.GenerateWithCustomExpiration(account, time.Now().Add(time.Duration(60*20)*time.Second))
+}
+
+// GenerateWithCustomExpiration generates a new token from an account with a custom expiration time signed with the active key
+func (issuer *Issuer) GenerateWithCustomExpiration(account Account, expiration time.Time) (serializedToken []byte, err error) {
+	issuer.mutex.RLock()
+	key := issuer.activeKey().SigningKey
+	issuer.mutex.RUnlock()
+
+	serializedToken, err = generate(issuer.name, key, account, expiration)
+
+	return
+}
+
+// ActiveKeyID returns the kid of the key that new tokens are signed with
+func (issuer *Issuer) ActiveKeyID() string {
+	issuer.mutex.RLock()
+	defer issuer.mutex.RUnlock()
+
+	return issuer.activeID
+}
+
+// AddKey adds a key that tokens can be verified with but that is not used for signing until it is promoted. Publishing the key, e.g. with JWKSHandler,
+// before promoting it gives the services that cache the keys time to fetch it before the first token signed with it arrives.
+func (issuer *Issuer) AddKey(key SigningKey) (err error) {
+	if key.Key == nil {
+		err = errors.New("The signing key is missing")
+		return
+	}
+
+	if key.Algorithm == "" {
+		key.Algorithm, err = DefaultAlgorithm(key.Key.Public())
+		if err != nil {
+			return
+		}
+	}
+
+	method, err := getSigningMethod(key.Algorithm)
+	if err != nil {
+		return
+	}
+
+	err = method.checkKey(key.Key.Public())
+	if err != nil {
+		return
+	}
+
+	if key.ID == "" {
+		key.ID, err = Thumbprint(key.Key.Public())
+		if err != nil {
+			return
+		}
+	}
+
+	issuer.mutex.Lock()
+	defer issuer.mutex.Unlock()
+
+	issuer.removeExpiredKeys()
+
+	if issuer.findKey(key.ID) >= 0 {
+		err = errors.New("There is already a key with kid " + key.ID)
+		return
+	}
+
+	issuer.keys = append(issuer.keys, issuerKey{SigningKey: key})
+
+	return
+}
+
+// Promote makes the key with keyID the active key, the previously active key can still be used to verify tokens during gracePeriod.
+// The grace period should be at least as long as the lifetime of the tokens, 20 minutes for Generate.
+func (issuer *Issuer) Promote(keyID string, gracePeriod time.Duration) (err error) {
+	issuer.mutex.Lock()
+	defer issuer.mutex.Unlock()
+
+	issuer.removeExpiredKeys()
+
+	index := issuer.findKey(keyID)
+	if index < 0 {
+		err = errors.New("There is no key with kid " + keyID)
+		return
+	}
+
+	if keyID == issuer.activeID {
+		return
+	}
+
+	previous := issuer.findKey(issuer.activeID)
+	issuer.keys[previous].verifyUntil = time.Now().Add(gracePeriod)
+	issuer.keys[index].verifyUntil = time.Time{}
+	issuer.activeID = keyID
+
+	return
+}
+
+// Rotate adds key and promotes it immediately, see AddKey and Promote
+func (issuer *Issuer) Rotate(key SigningKey, gracePeriod time.Duration) (err error) {
+	err = issuer.AddKey(key)
+	if err != nil {
+		return
+	}
+
+	if key.ID == "" {
+		key.ID, _ = Thumbprint(key.Key.Public())
+	}
+
+	err = issuer.Promote(key.ID, gracePeriod)
+
+	return
+}
+
+// Retire removes a key that is not active before its grace period ends, e.g. when it has been compromised, tokens signed with it are no longer valid
+func (issuer *Issuer) Retire(keyID string) (err error) {
+	issuer.mutex.Lock()
+	defer issuer.mutex.Unlock()
+
+	if keyID == issuer.activeID {
+		err = errors.New("The active key " + keyID + " can not be retired, promote another key first")
+		return
+	}
+
+	index := issuer.findKey(keyID)
+	if index < 0 {
+		err = errors.New("There is no key with kid " + keyID)
+		return
+	}
+
+	issuer.keys = append(issuer.keys[:index], issuer.keys[index+1:]...)
+
+	return
+}
+
+// VerificationKeys returns the public keys of the active key and of the keys that can still be used for verification
+func (issuer *Issuer) VerificationKeys() []VerificationKey {
+	issuer.mutex.RLock()
+	defer issuer.mutex.RUnlock()
+
+	now := time.Now()
+	keys := []VerificationKey{}
+
+	for _, key := range issuer.keys {
+		if key.verifyUntil.IsZero() || now.Before(key.verifyUntil) {
+			keys = append(keys, VerificationKey{ID: key.ID, Algorithm: key.Algorithm, Key: key.Key.Public()})
+		}
+	}
+
+	return keys
+}
+
+// GetVerificationKey returns the key with keyID if it can still be used for verification
+func (issuer *Issuer) GetVerificationKey(keyID string) (VerificationKey, error) {
+	return VerificationKeys(issuer.VerificationKeys()).GetVerificationKey(keyID)
+}
+
+func (issuer *Issuer) activeKey() issuerKey {
+	return issuer.keys[issuer.findKey(issuer.activeID)]
+}
+
+func (issuer *Issuer) findKey(keyID string) int {
+	for index, key := range issuer.keys {
+		if key.ID == keyID {
+			return index
+		}
+	}
+
+	return -1
+}
+
+func (issuer *Issuer) removeExpiredKeys() {
+	now := time.Now()
+	keys := issuer.keys[:0]
+
+	for _, key := range issuer.keys {
+		if key.verifyUntil.IsZero() || now.Before(key.verifyUntil) {
+			keys = append(keys, key)
+		}
+	}
+
+	issuer.keys = keys
+}
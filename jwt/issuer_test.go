@@ -0,0 +1,185 @@
+package jwt_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/SermoDigital/jose/jws"
+	"github.com/labstack/echo"
+	"github.com/mojlighetsministeriet/utils/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestAccount() *Account {
+	return &Account{ID: "a-user", Email: "a-user@example.com", Roles: []string{"user", "administrator"}}
+}
+
+func TestIssuerStampsKeyID(test *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(test, err)
+
+	issuer, err := jwt.NewIssuer("test-issuer", jwt.SigningKey{ID: "first", Key: privateKey})
+	assert.NoError(test, err)
+	assert.Equal(test, "first", issuer.ActiveKeyID())
+
+	token, err := issuer.Generate(newTestAccount())
+	assert.NoError(test, err)
+
+	parsedToken, err := jws.ParseJWT(token)
+	assert.NoError(test, err)
+	assert.Equal(test, "first", parsedToken.(jws.JWS).Protected().Get("kid"))
+	assert.Equal(test, "ES256", parsedToken.(jws.JWS).Protected().Get("alg"))
+
+	parsedToken, err = jwt.ParseIfValid(issuer, token)
+	assert.NoError(test, err)
+	assert.Equal(test, "test-issuer", parsedToken.Claims().Get("iss"))
+	assert.Equal(test, "a-user", parsedToken.Claims().Get("sub"))
+}
+
+func TestIssuerDefaultsKeyIDToThumbprint(test *testing.T) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(test, err)
+
+	thumbprint, err := jwt.Thumbprint(privateKey.Public())
+	assert.NoError(test, err)
+
+	issuer, err := jwt.NewIssuer("test-issuer", jwt.SigningKey{Key: privateKey})
+	assert.NoError(test, err)
+	assert.Equal(test, thumbprint, issuer.ActiveKeyID())
+}
+
+func TestIssuerRotate(test *testing.T) {
+	firstKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(test, err)
+
+	secondKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	assert.NoError(test, err)
+
+	issuer, err := jwt.NewIssuer("test-issuer", jwt.SigningKey{ID: "first", Key: firstKey})
+	assert.NoError(test, err)
+
+	oldToken, err := issuer.Generate(newTestAccount())
+	assert.NoError(test, err)
+
+	// The new key is published before it is used for signing
+	err = issuer.AddKey(jwt.SigningKey{ID: "second", Key: secondKey})
+	assert.NoError(test, err)
+	assert.Equal(test, "first", issuer.ActiveKeyID())
+	assert.Equal(test, 2, len(issuer.VerificationKeys()))
+
+	err = issuer.Promote("second", 100*time.Millisecond)
+	assert.NoError(test, err)
+	assert.Equal(test, "second", issuer.ActiveKeyID())
+
+	newToken, err := issuer.Generate(newTestAccount())
+	assert.NoError(test, err)
+
+	_, err = jwt.ParseIfValid(issuer, oldToken)
+	assert.NoError(test, err)
+
+	_, err = jwt.ParseIfValid(issuer, newToken)
+	assert.NoError(test, err)
+
+	time.Sleep(150 * time.Millisecond)
+
+	_, err = jwt.ParseIfValid(issuer, oldToken)
+	assert.Error(test, err)
+	assert.Equal(test, "There is no key with kid first", err.Error())
+
+	_, err = jwt.ParseIfValid(issuer, newToken)
+	assert.NoError(test, err)
+
+	keys := issuer.VerificationKeys()
+	assert.Equal(test, 1, len(keys))
+	assert.Equal(test, "second", keys[0].ID)
+	assert.Equal(test, jwt.ES384, keys[0].Algorithm)
+}
+
+func TestIssuerRetire(test *testing.T) {
+	firstKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(test, err)
+
+	secondKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(test, err)
+
+	issuer, err := jwt.NewIssuer("test-issuer", jwt.SigningKey{ID: "first", Key: firstKey})
+	assert.NoError(test, err)
+
+	oldToken, err := issuer.Generate(newTestAccount())
+	assert.NoError(test, err)
+
+	err = issuer.Rotate(jwt.SigningKey{ID: "second", Key: secondKey}, time.Hour)
+	assert.NoError(test, err)
+
+	err = issuer.Retire("second")
+	assert.Error(test, err)
+	assert.Equal(test, "The active key second can not be retired, promote another key first", err.Error())
+
+	err = issuer.Retire("first")
+	assert.NoError(test, err)
+
+	_, err = jwt.ParseIfValid(issuer, oldToken)
+	assert.Error(test, err)
+}
+
+func TestFailIssuerKeys(test *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(test, err)
+
+	_, err = jwt.NewIssuer("test-issuer", jwt.SigningKey{})
+	assert.Error(test, err)
+	assert.Equal(test, "The signing key is missing", err.Error())
+
+	_, err = jwt.NewIssuer("test-issuer", jwt.SigningKey{Algorithm: jwt.RS256, Key: privateKey})
+	assert.Error(test, err)
+	assert.Equal(test, "The key can not be used with algorithm RS256", err.Error())
+
+	issuer, err := jwt.NewIssuer("test-issuer", jwt.SigningKey{ID: "first", Key: privateKey})
+	assert.NoError(test, err)
+
+	err = issuer.AddKey(jwt.SigningKey{ID: "first", Key: privateKey})
+	assert.Error(test, err)
+	assert.Equal(test, "There is already a key with kid first", err.Error())
+
+	err = issuer.Promote("missing", time.Hour)
+	assert.Error(test, err)
+	assert.Equal(test, "There is no key with kid missing", err.Error())
+}
+
+func TestIssuerWithJWKSHandlerAndMiddleware(test *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(test, err)
+
+	issuer, err := jwt.NewIssuer("test-issuer", jwt.SigningKey{ID: "first", Key: privateKey})
+	assert.NoError(test, err)
+
+	recorder := httptest.NewRecorder()
+	context := echo.New().NewContext(httptest.NewRequest(echo.GET, jwt.JWKSPath, nil), recorder)
+	assert.NoError(test, jwt.JWKSHandler(issuer)(context))
+
+	keySet := jwt.JSONWebKeySet{}
+	assert.NoError(test, json.Unmarshal(recorder.Body.Bytes(), &keySet))
+	assert.Equal(test, 1, len(keySet.Keys))
+	assert.Equal(test, "first", keySet.Keys[0].KeyID)
+
+	token, err := issuer.Generate(newTestAccount())
+	assert.NoError(test, err)
+
+	request := httptest.NewRequest(echo.GET, "/", nil)
+	request.Header.Set("Authorization", "Bearer "+string(token))
+	recorder = httptest.NewRecorder()
+	context = echo.New().NewContext(request, recorder)
+
+	err = jwt.RequiredRoleMiddleware(issuer, "administrator")(func(context echo.Context) error {
+		return context.NoContent(http.StatusOK)
+	})(context)
+	assert.NoError(test, err)
+	assert.Equal(test, http.StatusOK, recorder.Code)
+}
@@ -0,0 +1,187 @@
+package jwt
+
+import (
+	gocrypto "crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+
+	"github.com/labstack/echo"
+)
+
+// JWKSPath is where a JSON Web Key Set is published by convention
+const JWKSPath = "/.well-known/jwks.json"
+
+// VerificationKey is a public key that tokens can be verified with, ID is used as kid and defaults to the RFC 7638 thumbprint of the key
+// and Algorithm defaults to DefaultAlgorithm for the key type
+type VerificationKey struct {
+	ID        string
+	Algorithm Algorithm
+	Key       gocrypto.PublicKey
+}
+
+// VerificationKeyProvider provides the keys that tokens can currently be verified with
+type VerificationKeyProvider interface {
+	VerificationKeys() []VerificationKey
+}
+
+// VerificationKeys is a fixed list of keys that implements VerificationKeyProvider
+type VerificationKeys []VerificationKey
+
+// VerificationKeys returns the keys
+func (keys VerificationKeys) VerificationKeys() []VerificationKey {
+	return keys
+}
+
+// JSONWebKey is the RFC 7517 representation of a public key
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid,omitempty"`
+	Use       string `json:"use,omitempty"`
+	Algorithm string `json:"alg,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+}
+
+// JSONWebKeySet is the RFC 7517 document published at JWKSPath
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// NewJSONWebKey converts a VerificationKey with an RSA, ECDSA (P-256 or P-384) or Ed25519 public key to a JSONWebKey
+func NewJSONWebKey(key VerificationKey) (webKey JSONWebKey, err error) {
+	algorithm := key.Algorithm
+	if algorithm == "" {
+		algorithm, err = DefaultAlgorithm(key.Key)
+		if err != nil {
+			return
+		}
+	}
+
+	method, err := getSigningMethod(algorithm)
+	if err != nil {
+		return
+	}
+
+	err = method.checkKey(key.Key)
+	if err != nil {
+		return
+	}
+
+	webKey = newJSONWebKeyMembers(key.Key)
+	webKey.Use = "sig"
+	webKey.Algorithm = string(algorithm)
+	webKey.KeyID = key.ID
+
+	if webKey.KeyID == "" {
+		webKey.KeyID, err = Thumbprint(key.Key)
+	}
+
+	return
+}
+
+// NewJSONWebKeySet converts every key from provider to a JSONWebKeySet
+func NewJSONWebKeySet(provider VerificationKeyProvider) (keySet JSONWebKeySet, err error) {
+	keySet.Keys = []JSONWebKey{}
+
+	for _, key := range provider.VerificationKeys() {
+		var webKey JSONWebKey
+		webKey, err = NewJSONWebKey(key)
+		if err != nil {
+			return
+		}
+
+		keySet.Keys = append(keySet.Keys, webKey)
+	}
+
+	return
+}
+
+// Thumbprint returns the RFC 7638 SHA-256 thumbprint of a public key, it is a stable key ID that does not need to be configured
+func Thumbprint(publicKey gocrypto.PublicKey) (thumbprint string, err error) {
+	_, err = algorithmsForKey(publicKey)
+	if err != nil {
+		return
+	}
+
+	webKey := newJSONWebKeyMembers(publicKey)
+
+	// The required members in lexicographic order as RFC 7638 specifies, the struct fields are marshalled in declaration order
+	var members interface{}
+	switch webKey.KeyType {
+	case "RSA":
+		members = struct {
+			E       string `json:"e"`
+			KeyType string `json:"kty"`
+			N       string `json:"n"`
+		}{webKey.E, webKey.KeyType, webKey.N}
+	case "EC":
+		members = struct {
+			Curve   string `json:"crv"`
+			KeyType string `json:"kty"`
+			X       string `json:"x"`
+			Y       string `json:"y"`
+		}{webKey.Curve, webKey.KeyType, webKey.X, webKey.Y}
+	default:
+		members = struct {
+			Curve   string `json:"crv"`
+			KeyType string `json:"kty"`
+			X       string `json:"x"`
+		}{webKey.Curve, webKey.KeyType, webKey.X}
+	}
+
+	data, err := json.Marshal(members)
+	if err != nil {
+		return
+	}
+
+	checksum := sha256.Sum256(data)
+	thumbprint = base64.RawURLEncoding.EncodeToString(checksum[:])
+
+	return
+}
+
+// JWKSHandler returns an echo handler that responds with the JSONWebKeySet of the keys currently provided by provider
+func JWKSHandler(provider VerificationKeyProvider) echo.HandlerFunc {
+	return func(context echo.Context) error {
+		keySet, err := NewJSONWebKeySet(provider)
+		if err != nil {
+			context.Logger().Error("Unable to create the JSON Web Key Set: " + err.Error())
+			return context.JSONBlob(http.StatusInternalServerError, []byte("{\"message\":\"Internal Server Error\"}"))
+		}
+
+		context.Response().Header().Set("Cache-Control", "public, max-age=300")
+
+		return context.JSON(http.StatusOK, keySet)
+	}
+}
+
+// newJSONWebKeyMembers sets the key type and the members describing a public key that has already been checked by algorithmsForKey
+func newJSONWebKeyMembers(publicKey gocrypto.PublicKey) (webKey JSONWebKey) {
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		webKey.KeyType = "RSA"
+		webKey.N = base64.RawURLEncoding.EncodeToString(key.N.Bytes())
+		webKey.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (key.Curve.Params().BitSize + 7) / 8
+		webKey.KeyType = "EC"
+		webKey.Curve = key.Curve.Params().Name
+		webKey.X = base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, size)))
+		webKey.Y = base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, size)))
+	case ed25519.PublicKey:
+		webKey.KeyType = "OKP"
+		webKey.Curve = "Ed25519"
+		webKey.X = base64.RawURLEncoding.EncodeToString(key)
+	}
+
+	return
+}
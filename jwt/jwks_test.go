@@ -0,0 +1,104 @@
+package jwt_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/mojlighetsministeriet/utils/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewJSONWebKey(test *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(test, err)
+
+	webKey, err := jwt.NewJSONWebKey(jwt.VerificationKey{ID: "an-rsa-key", Algorithm: jwt.PS256, Key: &rsaKey.PublicKey})
+	assert.NoError(test, err)
+	assert.Equal(test, "RSA", webKey.KeyType)
+	assert.Equal(test, "an-rsa-key", webKey.KeyID)
+	assert.Equal(test, "sig", webKey.Use)
+	assert.Equal(test, "PS256", webKey.Algorithm)
+	assert.Equal(test, "AQAB", webKey.E)
+
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	assert.NoError(test, err)
+
+	webKey, err = jwt.NewJSONWebKey(jwt.VerificationKey{Key: &p384Key.PublicKey})
+	assert.NoError(test, err)
+	assert.Equal(test, "EC", webKey.KeyType)
+	assert.Equal(test, "P-384", webKey.Curve)
+	assert.Equal(test, "ES384", webKey.Algorithm)
+	assert.Equal(test, 64, len(webKey.X))
+	assert.Equal(test, 64, len(webKey.Y))
+	assert.Equal(test, 43, len(webKey.KeyID))
+
+	ed25519Key, _, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(test, err)
+
+	webKey, err = jwt.NewJSONWebKey(jwt.VerificationKey{Key: ed25519Key})
+	assert.NoError(test, err)
+	assert.Equal(test, "OKP", webKey.KeyType)
+	assert.Equal(test, "Ed25519", webKey.Curve)
+	assert.Equal(test, "EdDSA", webKey.Algorithm)
+}
+
+func TestFailNewJSONWebKey(test *testing.T) {
+	p256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(test, err)
+
+	_, err = jwt.NewJSONWebKey(jwt.VerificationKey{Algorithm: jwt.RS256, Key: &p256Key.PublicKey})
+	assert.Error(test, err)
+	assert.Equal(test, "The key can not be used with algorithm RS256", err.Error())
+
+	_, err = jwt.NewJSONWebKey(jwt.VerificationKey{Key: "not a key"})
+	assert.Error(test, err)
+	assert.Equal(test, "Unsupported key type", err.Error())
+}
+
+func TestThumbprint(test *testing.T) {
+	// The example key from RFC 7638 section 3.1
+	modulus, err := base64.RawURLEncoding.DecodeString("0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw")
+	assert.NoError(test, err)
+
+	thumbprint, err := jwt.Thumbprint(&rsa.PublicKey{N: new(big.Int).SetBytes(modulus), E: 65537})
+	assert.NoError(test, err)
+	assert.Equal(test, "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs", thumbprint)
+}
+
+func TestJWKSHandler(test *testing.T) {
+	p256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(test, err)
+
+	server := echo.New()
+	recorder := httptest.NewRecorder()
+	context := server.NewContext(httptest.NewRequest(echo.GET, jwt.JWKSPath, nil), recorder)
+
+	err = jwt.JWKSHandler(jwt.VerificationKeys{{ID: "a-key", Key: &p256Key.PublicKey}})(context)
+	assert.NoError(test, err)
+	assert.Equal(test, http.StatusOK, recorder.Code)
+
+	keySet := map[string][]map[string]string{}
+	assert.NoError(test, json.Unmarshal(recorder.Body.Bytes(), &keySet))
+	assert.Equal(test, "a-key", keySet["keys"][0]["kid"])
+	assert.Equal(test, "EC", keySet["keys"][0]["kty"])
+	assert.Equal(test, "P-256", keySet["keys"][0]["crv"])
+
+	recorder = httptest.NewRecorder()
+	context = server.NewContext(httptest.NewRequest(echo.GET, jwt.JWKSPath, nil), recorder)
+
+	err = jwt.JWKSHandler(jwt.VerificationKeys{{Key: "not a key"}})(context)
+	assert.NoError(test, err)
+	assert.Equal(test, http.StatusInternalServerError, recorder.Code)
+	assert.Equal(test, true, strings.Contains(recorder.Body.String(), "Internal Server Error"))
+}
@@ -0,0 +1,178 @@
+package jwt
+
+import (
+	gocrypto "crypto"
+	"crypto/tls"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mojlighetsministeriet/utils"
+)
+
+// DefaultKeyFetchTimeout is how long fetching a key over HTTP may take
+const DefaultKeyFetchTimeout = 10 * time.Second
+
+// ParsePublicKey returns the first RSA, ECDSA (P-256 or P-384) or Ed25519 public key in PEM encoded data. PKIX public keys (PUBLIC KEY), PKCS #1 RSA public keys
+// (RSA PUBLIC KEY) and certificates are read and if there is none the public key of a private key (see ParsePrivateKey) is used.
+func ParsePublicKey(data []byte) (publicKey gocrypto.PublicKey, err error) {
+	publicKeys, err := parsePEMPublicKeys(data)
+	if err == nil {
+		publicKey = publicKeys[0]
+		return
+	}
+
+	privateKey, privateKeyErr := parsePEMPrivateKey(data)
+	if privateKeyErr == nil {
+		publicKey = privateKey.Public()
+		err = nil
+	}
+
+	return
+}
+
+// ParsePrivateKey returns the first RSA, ECDSA (P-256 or P-384) or Ed25519 private key in PEM encoded PKCS #8 (PRIVATE KEY), PKCS #1 (RSA PRIVATE KEY) or SEC 1 (EC PRIVATE KEY) data
+func ParsePrivateKey(data []byte) (privateKey gocrypto.Signer, err error) {
+	privateKey, err = parsePEMPrivateKey(data)
+	return
+}
+
+// LoadPublicKeyFromFile reads a public key from a PEM encoded file, see ParsePublicKey
+func LoadPublicKeyFromFile(filename string) (publicKey gocrypto.PublicKey, err error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return
+	}
+
+	publicKey, err = ParsePublicKey(data)
+	if err != nil {
+		err = errors.New("Unable to load public key from " + filename + ": " + err.Error())
+	}
+
+	return
+}
+
+// LoadPrivateKeyFromFile reads a private key from a PEM encoded file, see ParsePrivateKey
+func LoadPrivateKeyFromFile(filename string) (privateKey gocrypto.Signer, err error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return
+	}
+
+	privateKey, err = ParsePrivateKey(data)
+	if err != nil {
+		err = errors.New("Unable to load private key from " + filename + ": " + err.Error())
+	}
+
+	return
+}
+
+// LoadPublicKeyFromEnv reads a PEM encoded public key from an environment variable, or the file KEY_FILE points to (see utils.GetEnv).
+// Escaped newlines (\n) are allowed since PEM is often put on one line in environment files. The variable is marked as secret with utils.MarkConfigSecret.
+func LoadPublicKeyFromEnv(key string) (publicKey gocrypto.PublicKey, err error) {
+	data, err := getPEMFromEnv(key)
+	if err != nil {
+		return
+	}
+
+	publicKey, err = ParsePublicKey(data)
+	if err != nil {
+		err = errors.New("Unable to load public key from " + key + ": " + err.Error())
+	}
+
+	return
+}
+
+// LoadPrivateKeyFromEnv reads a PEM encoded private key from an environment variable, or the file KEY_FILE points to, the same way as LoadPublicKeyFromEnv
+func LoadPrivateKeyFromEnv(key string) (privateKey gocrypto.Signer, err error) {
+	data, err := getPEMFromEnv(key)
+	if err != nil {
+		return
+	}
+
+	privateKey, err = ParsePrivateKey(data)
+	if err != nil {
+		err = errors.New("Unable to load private key from " + key + ": " + err.Error())
+	}
+
+	return
+}
+
+// LoadPublicKeyFromURL fetches a PEM encoded public key over HTTP, HTTPS connections are verified with utils.GetSystemCACertificatesTLSConfig
+func LoadPublicKeyFromURL(url string) (publicKey gocrypto.PublicKey, err error) {
+	if !strings.HasPrefix(strings.ToLower(url), "https://") {
+		publicKey, err = fetchPublicKey(&http.Client{Timeout: DefaultKeyFetchTimeout}, url)
+		return
+	}
+
+	tlsConfig, err := utils.GetSystemCACertificatesTLSConfig()
+	if err != nil {
+		return
+	}
+
+	publicKey, err = LoadPublicKeyFromURLWithTLSConfig(url, tlsConfig)
+
+	return
+}
+
+// LoadPublicKeyFromURLWithTLSConfig works like LoadPublicKeyFromURL but with a custom TLS config e.g. from a utils.TrustStore for an internal CA
+func LoadPublicKeyFromURLWithTLSConfig(url string, tlsConfig *tls.Config) (publicKey gocrypto.PublicKey, err error) {
+	client := &http.Client{
+		Timeout:   DefaultKeyFetchTimeout,
+		Transport: &http.Transport{TLSClientConfig: utils.ApplyDefaultTLSProfile(tlsConfig)},
+	}
+
+	publicKey, err = fetchPublicKey(client, url)
+
+	return
+}
+
+func fetchPublicKey(client *http.Client, url string) (publicKey gocrypto.PublicKey, err error) {
+	response, err := client.Get(url)
+	if err != nil {
+		return
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		err = errors.New("Failed to fetch public key from " + url + ": " + response.Status)
+		return
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(response.Body, MaximumKeySetSize+1))
+	if err != nil {
+		return
+	}
+
+	if len(body) > MaximumKeySetSize {
+		err = errors.New("The public key from " + url + " is larger than " + strconv.Itoa(MaximumKeySetSize) + " bytes")
+		return
+	}
+
+	publicKey, err = ParsePublicKey(body)
+	if err != nil {
+		err = errors.New("Unable to load public key from " + url + ": " + err.Error())
+	}
+
+	return
+}
+
+// getPEMFromEnv marks key as secret before reading it, the variable may hold a private key and must be masked by utils.GetConfigRecords
+func getPEMFromEnv(key string) (data []byte, err error) {
+	utils.MarkConfigSecret(key)
+
+	value := utils.GetEnv(key, "")
+	if value == "" {
+		err = errors.New("The environment variable " + key + " is not set")
+		return
+	}
+
+	data = []byte(strings.Replace(value, "\\n", "\n", -1))
+
+	return
+}
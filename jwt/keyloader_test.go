@@ -0,0 +1,288 @@
+package jwt_test
+
+import (
+	"bytes"
+	gocrypto "crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mojlighetsministeriet/utils"
+	"github.com/mojlighetsministeriet/utils/jwt"
+	"github.com/mojlighetsministeriet/utils/tlstest"
+	"github.com/stretchr/testify/assert"
+)
+
+func encodePEM(blockType string, data []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: data})
+}
+
+func TestParsePublicKey(test *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(test, err)
+
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	assert.NoError(test, err)
+
+	_, ed25519Key, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(test, err)
+
+	for _, privateKey := range []gocrypto.Signer{rsaKey, ecdsaKey, ed25519Key} {
+		pkix, err := x509.MarshalPKIXPublicKey(privateKey.Public())
+		assert.NoError(test, err)
+
+		publicKey, err := jwt.ParsePublicKey(encodePEM("PUBLIC KEY", pkix))
+		assert.NoError(test, err)
+		assert.Equal(test, privateKey.Public(), publicKey)
+
+		pkcs8, err := x509.MarshalPKCS8PrivateKey(privateKey)
+		assert.NoError(test, err)
+
+		publicKey, err = jwt.ParsePublicKey(encodePEM("PRIVATE KEY", pkcs8))
+		assert.NoError(test, err)
+		assert.Equal(test, privateKey.Public(), publicKey)
+	}
+
+	publicKey, err := jwt.ParsePublicKey(encodePEM("RSA PUBLIC KEY", x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey)))
+	assert.NoError(test, err)
+	assert.Equal(test, &rsaKey.PublicKey, publicKey)
+
+	authority, err := tlstest.NewCertificateAuthority()
+	assert.NoError(test, err)
+
+	publicKey, err = jwt.ParsePublicKey(authority.CertificatePEM)
+	assert.NoError(test, err)
+	assert.Equal(test, authority.Certificate.PublicKey, publicKey)
+}
+
+func TestParsePrivateKey(test *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(test, err)
+
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(test, err)
+
+	_, ed25519Key, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(test, err)
+
+	for _, expected := range []gocrypto.Signer{rsaKey, ecdsaKey, ed25519Key} {
+		pkcs8, err := x509.MarshalPKCS8PrivateKey(expected)
+		assert.NoError(test, err)
+
+		privateKey, err := jwt.ParsePrivateKey(encodePEM("PRIVATE KEY", pkcs8))
+		assert.NoError(test, err)
+		assert.Equal(test, expected.Public(), privateKey.Public())
+	}
+
+	privateKey, err := jwt.ParsePrivateKey(encodePEM("RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey)))
+	assert.NoError(test, err)
+	assert.Equal(test, rsaKey.Public(), privateKey.Public())
+
+	sec1, err := x509.MarshalECPrivateKey(ecdsaKey)
+	assert.NoError(test, err)
+
+	privateKey, err = jwt.ParsePrivateKey(encodePEM("EC PRIVATE KEY", sec1))
+	assert.NoError(test, err)
+	assert.Equal(test, ecdsaKey.Public(), privateKey.Public())
+}
+
+func TestFailParseKeys(test *testing.T) {
+	_, err := jwt.ParsePublicKey([]byte("bad pem text"))
+	assert.Error(test, err)
+	assert.Equal(test, "Unable to decode pem", err.Error())
+
+	_, err = jwt.ParsePublicKey(encodePEM("PUBLIC KEY", []byte("garbage")))
+	assert.Error(test, err)
+	assert.True(test, strings.HasPrefix(err.Error(), "Unable to parse PUBLIC KEY: "))
+
+	unsupportedKey, err := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+	assert.NoError(test, err)
+
+	pkix, err := x509.MarshalPKIXPublicKey(unsupportedKey.Public())
+	assert.NoError(test, err)
+
+	_, err = jwt.ParsePublicKey(encodePEM("PUBLIC KEY", pkix))
+	assert.Error(test, err)
+	assert.Equal(test, "Unable to use PUBLIC KEY: Unsupported elliptic curve P-224", err.Error())
+
+	_, err = jwt.ParsePrivateKey(encodePEM("PUBLIC KEY", pkix))
+	assert.Error(test, err)
+	assert.Equal(test, "Unable to find a PRIVATE KEY, RSA PRIVATE KEY or EC PRIVATE KEY in pem", err.Error())
+
+	_, err = jwt.ParsePrivateKey(encodePEM("RSA PRIVATE KEY", []byte("garbage")))
+	assert.Error(test, err)
+	assert.True(test, strings.HasPrefix(err.Error(), "Unable to parse RSA PRIVATE KEY: "))
+}
+
+func TestParsePublicKeySkipsUnsupportedKeysInBundle(test *testing.T) {
+	unsupportedKey, err := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+	assert.NoError(test, err)
+
+	unsupportedPKIX, err := x509.MarshalPKIXPublicKey(unsupportedKey.Public())
+	assert.NoError(test, err)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(test, err)
+
+	pkix, err := x509.MarshalPKIXPublicKey(key.Public())
+	assert.NoError(test, err)
+
+	bundle := append(encodePEM("PUBLIC KEY", unsupportedPKIX), encodePEM("PUBLIC KEY", []byte("garbage"))...)
+	bundle = append(bundle, encodePEM("PUBLIC KEY", pkix)...)
+
+	publicKey, err := jwt.ParsePublicKey(bundle)
+	assert.NoError(test, err)
+	assert.Equal(test, key.Public(), publicKey)
+}
+
+func TestLoadKeysFromFile(test *testing.T) {
+	directory, err := ioutil.TempDir("", "keyloader")
+	assert.NoError(test, err)
+	defer os.RemoveAll(directory)
+
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(test, err)
+
+	sec1, err := x509.MarshalECPrivateKey(privateKey)
+	assert.NoError(test, err)
+
+	filename := filepath.Join(directory, "key.pem")
+	assert.NoError(test, ioutil.WriteFile(filename, encodePEM("EC PRIVATE KEY", sec1), 0600))
+
+	loadedPrivateKey, err := jwt.LoadPrivateKeyFromFile(filename)
+	assert.NoError(test, err)
+	assert.Equal(test, privateKey.Public(), loadedPrivateKey.Public())
+
+	publicKey, err := jwt.LoadPublicKeyFromFile(filename)
+	assert.NoError(test, err)
+	assert.Equal(test, privateKey.Public(), publicKey)
+
+	badFilename := filepath.Join(directory, "bad.pem")
+	assert.NoError(test, ioutil.WriteFile(badFilename, []byte("bad pem text"), 0600))
+
+	_, err = jwt.LoadPublicKeyFromFile(badFilename)
+	assert.Error(test, err)
+	assert.Equal(test, "Unable to load public key from "+badFilename+": Unable to decode pem", err.Error())
+
+	_, err = jwt.LoadPrivateKeyFromFile(filepath.Join(directory, "missing.pem"))
+	assert.Error(test, err)
+}
+
+func TestLoadKeysFromEnv(test *testing.T) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(test, err)
+
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	assert.NoError(test, err)
+
+	os.Setenv("JWT_TEST_PRIVATE_KEY", strings.Replace(string(encodePEM("PRIVATE KEY", pkcs8)), "\n", "\\n", -1))
+	defer os.Unsetenv("JWT_TEST_PRIVATE_KEY")
+
+	loadedPrivateKey, err := jwt.LoadPrivateKeyFromEnv("JWT_TEST_PRIVATE_KEY")
+	assert.NoError(test, err)
+	assert.Equal(test, privateKey.Public(), loadedPrivateKey.Public())
+
+	publicKey, err := jwt.LoadPublicKeyFromEnv("JWT_TEST_PRIVATE_KEY")
+	assert.NoError(test, err)
+	assert.Equal(test, privateKey.Public(), publicKey)
+
+	_, err = jwt.LoadPublicKeyFromEnv("JWT_TEST_MISSING_KEY")
+	assert.Error(test, err)
+	assert.Equal(test, "The environment variable JWT_TEST_MISSING_KEY is not set", err.Error())
+}
+
+func TestLoadPrivateKeyFromEnvMasksConfigRecord(test *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(test, err)
+
+	sec1, err := x509.MarshalECPrivateKey(privateKey)
+	assert.NoError(test, err)
+
+	os.Setenv("JWT_TEST_SIGNING_KEY", string(encodePEM("EC PRIVATE KEY", sec1)))
+	defer os.Unsetenv("JWT_TEST_SIGNING_KEY")
+
+	_, err = jwt.LoadPrivateKeyFromEnv("JWT_TEST_SIGNING_KEY")
+	assert.NoError(test, err)
+
+	found := false
+	for _, record := range utils.GetConfigRecords() {
+		if record.Key == "JWT_TEST_SIGNING_KEY" {
+			found = true
+			assert.Equal(test, true, record.Secret)
+			assert.Equal(test, utils.MaskedConfigValue, record.Value)
+		}
+	}
+
+	assert.Equal(test, true, found)
+}
+
+func TestLoadPublicKeyFromURL(test *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(test, err)
+
+	pkix, err := x509.MarshalPKIXPublicKey(privateKey.Public())
+	assert.NoError(test, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Write(encodePEM("PUBLIC KEY", pkix))
+	}))
+	defer server.Close()
+
+	publicKey, err := jwt.LoadPublicKeyFromURL(server.URL)
+	assert.NoError(test, err)
+	assert.Equal(test, privateKey.Public(), publicKey)
+}
+
+func TestFailLoadPublicKeyFromURLWithLargeBody(test *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Write(bytes.Repeat([]byte(" "), jwt.MaximumKeySetSize+1))
+	}))
+	defer server.Close()
+
+	_, err := jwt.LoadPublicKeyFromURL(server.URL)
+	assert.Error(test, err)
+	assert.Equal(test, "The public key from "+server.URL+" is larger than 1048576 bytes", err.Error())
+
+	_, err = jwt.FetchPublicKey(server.URL)
+	assert.Error(test, err)
+	assert.Equal(test, "The public key from "+server.URL+" is larger than 1048576 bytes", err.Error())
+}
+
+func TestLoadPublicKeyFromURLWithTLSConfig(test *testing.T) {
+	authority, err := tlstest.NewCertificateAuthority()
+	assert.NoError(test, err)
+
+	serverCertificate, err := authority.IssueServerCertificate("127.0.0.1")
+	assert.NoError(test, err)
+
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(test, err)
+
+	pkix, err := x509.MarshalPKIXPublicKey(privateKey.Public())
+	assert.NoError(test, err)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Write(encodePEM("PUBLIC KEY", pkix))
+	}))
+	server.TLS = serverCertificate.ServerTLSConfig()
+	server.StartTLS()
+	defer server.Close()
+
+	publicKey, err := jwt.LoadPublicKeyFromURLWithTLSConfig(server.URL, authority.ClientTLSConfig())
+	assert.NoError(test, err)
+	assert.Equal(test, privateKey.Public(), publicKey)
+
+	_, err = jwt.LoadPublicKeyFromURLWithTLSConfig(server.URL+"/", nil)
+	assert.Error(test, err)
+}
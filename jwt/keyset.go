@@ -0,0 +1,152 @@
+package jwt
+
+import (
+	gocrypto "crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"strconv"
+)
+
+// KeySet is a set of keys that can be passed as publicKey to ParseIfValid, GetClaimsFromContextIfValid and RequiredRoleMiddleware,
+// the key a token is verified with is selected by the kid header of the token
+type KeySet interface {
+	GetVerificationKey(keyID string) (key VerificationKey, err error)
+}
+
+// GetVerificationKey returns the key with keyID, if keyID is empty and there is only one key that key is returned
+func (keys VerificationKeys) GetVerificationKey(keyID string) (key VerificationKey, err error) {
+	if keyID == "" && len(keys) == 1 {
+		key = keys[0]
+		return
+	}
+
+	for _, candidate := range keys {
+		if candidate.ID == "" {
+			candidate.ID, _ = Thumbprint(candidate.Key)
+		}
+
+		if keyID != "" && candidate.ID == keyID {
+			key = candidate
+			return
+		}
+	}
+
+	if keyID == "" {
+		err = errors.New("The token has no kid and there are " + strconv.Itoa(len(keys)) + " keys to choose from")
+	} else {
+		err = errors.New("There is no key with kid " + keyID)
+	}
+
+	return
+}
+
+// PublicKey returns the RSA, ECDSA or Ed25519 public key described by the JSONWebKey
+func (webKey JSONWebKey) PublicKey() (publicKey gocrypto.PublicKey, err error) {
+	switch webKey.KeyType {
+	case "RSA":
+		var modulus, exponent *big.Int
+		modulus, err = decodeBase64URLInt(webKey.N)
+		if err != nil {
+			return
+		}
+
+		exponent, err = decodeBase64URLInt(webKey.E)
+		if err != nil {
+			return
+		}
+
+		if !exponent.IsInt64() || exponent.Int64() > 1<<31-1 {
+			err = errors.New("The RSA exponent of key " + webKey.KeyID + " is too large")
+			return
+		}
+
+		publicKey = &rsa.PublicKey{N: modulus, E: int(exponent.Int64())}
+
+	case "EC":
+		var curve elliptic.Curve
+		switch webKey.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			err = errors.New("Unsupported elliptic curve " + webKey.Curve)
+			return
+		}
+
+		var x, y *big.Int
+		x, err = decodeBase64URLInt(webKey.X)
+		if err != nil {
+			return
+		}
+
+		y, err = decodeBase64URLInt(webKey.Y)
+		if err != nil {
+			return
+		}
+
+		if !curve.IsOnCurve(x, y) {
+			err = errors.New("The point of key " + webKey.KeyID + " is not on the curve " + webKey.Curve)
+			return
+		}
+
+		publicKey = &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+
+	case "OKP":
+		if webKey.Curve != "Ed25519" {
+			err = errors.New("Unsupported curve " + webKey.Curve)
+			return
+		}
+
+		var x []byte
+		x, err = base64.RawURLEncoding.DecodeString(webKey.X)
+		if err != nil {
+			return
+		}
+
+		if len(x) != ed25519.PublicKeySize {
+			err = errors.New("The Ed25519 key " + webKey.KeyID + " has the wrong size")
+			return
+		}
+
+		publicKey = ed25519.PublicKey(x)
+
+	default:
+		err = errors.New("Unsupported key type " + webKey.KeyType)
+	}
+
+	return
+}
+
+// VerificationKey returns the key together with its kid and alg
+func (webKey JSONWebKey) VerificationKey() (key VerificationKey, err error) {
+	publicKey, err := webKey.PublicKey()
+	if err != nil {
+		return
+	}
+
+	key = VerificationKey{ID: webKey.KeyID, Algorithm: Algorithm(webKey.Algorithm), Key: publicKey}
+
+	return
+}
+
+func decodeBase64URLInt(encoded string) (value *big.Int, err error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return
+	}
+
+	if len(data) == 0 {
+		err = errors.New("Missing key parameter")
+		return
+	}
+
+	value = new(big.Int).SetBytes(data)
+
+	return
+}
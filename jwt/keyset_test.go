@@ -0,0 +1,91 @@
+package jwt_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+
+	"github.com/mojlighetsministeriet/utils/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerificationKeysGetVerificationKey(test *testing.T) {
+	firstKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(test, err)
+
+	secondKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(test, err)
+
+	thumbprint, err := jwt.Thumbprint(secondKey.Public())
+	assert.NoError(test, err)
+
+	keys := jwt.VerificationKeys{{ID: "first", Key: firstKey.Public()}, {Key: secondKey.Public()}}
+
+	key, err := keys.GetVerificationKey("first")
+	assert.NoError(test, err)
+	assert.Equal(test, firstKey.Public(), key.Key)
+
+	key, err = keys.GetVerificationKey(thumbprint)
+	assert.NoError(test, err)
+	assert.Equal(test, secondKey.Public(), key.Key)
+
+	key, err = keys[:1].GetVerificationKey("")
+	assert.NoError(test, err)
+	assert.Equal(test, firstKey.Public(), key.Key)
+}
+
+func TestFailVerificationKeysGetVerificationKey(test *testing.T) {
+	firstKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(test, err)
+
+	secondKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(test, err)
+
+	keys := jwt.VerificationKeys{{ID: "first", Key: firstKey.Public()}, {ID: "second", Key: secondKey.Public()}}
+
+	_, err = keys.GetVerificationKey("")
+	assert.Error(test, err)
+	assert.Equal(test, "The token has no kid and there are 2 keys to choose from", err.Error())
+
+	_, err = keys.GetVerificationKey("third")
+	assert.Error(test, err)
+	assert.Equal(test, "There is no key with kid third", err.Error())
+}
+
+func TestJSONWebKeyPublicKey(test *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(test, err)
+
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	assert.NoError(test, err)
+
+	ed25519Key, _, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(test, err)
+
+	for _, publicKey := range []interface{}{rsaKey.Public(), ecdsaKey.Public(), ed25519Key} {
+		webKey, err := jwt.NewJSONWebKey(jwt.VerificationKey{ID: "key", Key: publicKey})
+		assert.NoError(test, err)
+
+		key, err := webKey.VerificationKey()
+		assert.NoError(test, err)
+		assert.Equal(test, "key", key.ID)
+		assert.Equal(test, publicKey, key.Key)
+	}
+}
+
+func TestFailJSONWebKeyPublicKey(test *testing.T) {
+	_, err := jwt.JSONWebKey{KeyType: "oct"}.PublicKey()
+	assert.Error(test, err)
+	assert.Equal(test, "Unsupported key type oct", err.Error())
+
+	_, err = jwt.JSONWebKey{KeyType: "EC", Curve: "P-521"}.PublicKey()
+	assert.Error(test, err)
+	assert.Equal(test, "Unsupported elliptic curve P-521", err.Error())
+
+	_, err = jwt.JSONWebKey{KeyID: "key", KeyType: "EC", Curve: "P-256", X: "AQ", Y: "AQ"}.PublicKey()
+	assert.Error(test, err)
+	assert.Equal(test, "The point of key key is not on the curve P-256", err.Error())
+}
@@ -0,0 +1,90 @@
+package jwt
+
+import (
+	"sync"
+	"time"
+)
+
+// MemoryRefreshTokenStore is a RefreshTokenStore that keeps the tokens in memory, it is meant for tests and services with a single instance
+// since the tokens are lost on restart. Expired tokens, and revoked families whose tokens have all expired, are removed when new tokens are saved.
+type MemoryRefreshTokenStore struct {
+	mutex           sync.Mutex
+	tokens          map[string]RefreshToken
+	revokedFamilies map[string]time.Time
+}
+
+// NewMemoryRefreshTokenStore creates an empty MemoryRefreshTokenStore
+func NewMemoryRefreshTokenStore() *MemoryRefreshTokenStore {
+	return &MemoryRefreshTokenStore{tokens: make(map[string]RefreshToken), revokedFamilies: make(map[string]time.Time)}
+}
+
+// Save stores a new refresh token, ErrRefreshTokenRevoked is returned if its family has been revoked
+func (store *MemoryRefreshTokenStore) Save(token RefreshToken) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	now := time.Now()
+	for id, stored := range store.tokens {
+		if !now.Before(stored.ExpiresAt) {
+			delete(store.tokens, id)
+		}
+	}
+
+	for familyID, expiresAt := range store.revokedFamilies {
+		if !now.Before(expiresAt) {
+			delete(store.revokedFamilies, familyID)
+		}
+	}
+
+	if _, revoked := store.revokedFamilies[token.FamilyID]; revoked {
+		return ErrRefreshTokenRevoked
+	}
+
+	store.tokens[token.ID] = token
+
+	return nil
+}
+
+// Use marks the token with id as used and returns it as it was before
+func (store *MemoryRefreshTokenStore) Use(id string) (token RefreshToken, err error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	token, found := store.tokens[id]
+	if !found {
+		err = ErrRefreshTokenNotFound
+		return
+	}
+
+	if _, revoked := store.revokedFamilies[token.FamilyID]; revoked {
+		token.Revoked = true
+	}
+
+	used := token
+	used.Used = true
+	store.tokens[id] = used
+
+	return
+}
+
+// RevokeFamily marks every token in a family as revoked and remembers the family, until its tokens have expired, so no more tokens can be saved into it
+func (store *MemoryRefreshTokenStore) RevokeFamily(familyID string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	expiresAt := store.revokedFamilies[familyID]
+	for id, token := range store.tokens {
+		if token.FamilyID == familyID {
+			token.Revoked = true
+			store.tokens[id] = token
+
+			if token.ExpiresAt.After(expiresAt) {
+				expiresAt = token.ExpiresAt
+			}
+		}
+	}
+
+	store.revokedFamilies[familyID] = expiresAt
+
+	return nil
+}
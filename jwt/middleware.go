@@ -1,7 +1,7 @@
 package jwt
 
 import (
-	"crypto/rsa"
+	gocrypto "crypto"
 	"net/http"
 	"strings"
 
@@ -9,7 +9,7 @@ import (
 )
 
 // RequiredRoleMiddleware is a echo middleware that will allow to restrict access to a JWT token containing a specific user role
-func RequiredRoleMiddleware(publicKey *rsa.PublicKey, requiredRole string) echo.MiddlewareFunc {
+func RequiredRoleMiddleware(publicKey gocrypto.PublicKey, requiredRole string) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(context echo.Context) error {
 			token := GetTokenFromContext(context)
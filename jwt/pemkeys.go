@@ -0,0 +1,113 @@
+package jwt
+
+import (
+	gocrypto "crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+)
+
+// parsePEMPublicKeys returns the public keys in PEM encoded PKIX public keys (PUBLIC KEY), PKCS #1 RSA public keys (RSA PUBLIC KEY) and certificates.
+// Keys that can not be parsed or used are skipped so a bundle can contain e.g. a CA with a P-521 key, the error for the first of them is returned if no key is usable.
+func parsePEMPublicKeys(data []byte) (publicKeys []gocrypto.PublicKey, err error) {
+	var skippedErr error
+
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+
+		var publicKey gocrypto.PublicKey
+
+		switch block.Type {
+		case "PUBLIC KEY":
+			publicKey, err = x509.ParsePKIXPublicKey(block.Bytes)
+		case "RSA PUBLIC KEY":
+			publicKey, err = x509.ParsePKCS1PublicKey(block.Bytes)
+		case "CERTIFICATE":
+			var certificate *x509.Certificate
+			certificate, err = x509.ParseCertificate(block.Bytes)
+			if err == nil {
+				publicKey = certificate.PublicKey
+			}
+		default:
+			continue
+		}
+
+		if err != nil {
+			if skippedErr == nil {
+				skippedErr = errors.New("Unable to parse " + block.Type + ": " + err.Error())
+			}
+			continue
+		}
+
+		_, err = algorithmsForKey(publicKey)
+		if err != nil {
+			if skippedErr == nil {
+				skippedErr = errors.New("Unable to use " + block.Type + ": " + err.Error())
+			}
+			continue
+		}
+
+		publicKeys = append(publicKeys, publicKey)
+	}
+
+	err = nil
+
+	if len(publicKeys) == 0 {
+		err = skippedErr
+		if err == nil {
+			err = errors.New("Unable to decode pem")
+		}
+	}
+
+	return
+}
+
+// parsePEMPrivateKey returns the first private key in PEM encoded PKCS #8 (PRIVATE KEY), PKCS #1 (RSA PRIVATE KEY) or SEC 1 (EC PRIVATE KEY) data
+func parsePEMPrivateKey(data []byte) (privateKey gocrypto.Signer, err error) {
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			err = errors.New("Unable to find a PRIVATE KEY, RSA PRIVATE KEY or EC PRIVATE KEY in pem")
+			return
+		}
+
+		var key interface{}
+
+		switch block.Type {
+		case "PRIVATE KEY":
+			key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+		case "RSA PRIVATE KEY":
+			key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+		case "EC PRIVATE KEY":
+			key, err = x509.ParseECPrivateKey(block.Bytes)
+		default:
+			continue
+		}
+
+		if err != nil {
+			err = errors.New("Unable to parse " + block.Type + ": " + err.Error())
+			return
+		}
+
+		signer, ok := key.(gocrypto.Signer)
+		if !ok {
+			err = errors.New("Unable to use " + block.Type + ": Unsupported key type")
+			return
+		}
+
+		_, err = algorithmsForKey(signer.Public())
+		if err != nil {
+			err = errors.New("Unable to use " + block.Type + ": " + err.Error())
+			return
+		}
+
+		privateKey = signer
+
+		return
+	}
+}
@@ -0,0 +1,192 @@
+package jwt
+
+import (
+	gocrypto "crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// DefaultRefreshTokenLifetime is how long a refresh token can be used when no lifetime is chosen
+const DefaultRefreshTokenLifetime = 30 * 24 * time.Hour
+
+// ErrRefreshTokenNotFound is returned by a RefreshTokenStore when there is no token with the ID
+var ErrRefreshTokenNotFound = errors.New("The refresh token is not valid")
+
+// ErrRefreshTokenReused is returned by Rotate when a refresh token that has already been rotated is used again, which means that it has been stolen
+// by someone, so every refresh token in its family has been revoked and the account has to log in again
+var ErrRefreshTokenReused = errors.New("The refresh token has already been used, all refresh tokens in its family have been revoked")
+
+// ErrRefreshTokenRevoked is returned by Rotate for a token in a revoked family and by a RefreshTokenStore when a token is saved into a revoked family
+var ErrRefreshTokenRevoked = errors.New("The refresh token has been revoked")
+
+// RefreshToken is what a RefreshTokenStore keeps about an issued refresh token. The ID is the SHA-256 hash of the token so the stored data can not be used as a token.
+// Every token that is rotated from the same login shares FamilyID.
+type RefreshToken struct {
+	ID        string
+	FamilyID  string
+	AccountID string
+	ExpiresAt time.Time
+	Used      bool
+	Revoked   bool
+}
+
+// RefreshTokenStore keeps the issued refresh tokens, e.g. in a database shared by all instances of a service.
+// A revoked family must stay revoked, including for tokens saved into it by a rotation that was in progress when it was revoked.
+type RefreshTokenStore interface {
+	// Save stores a new refresh token, ErrRefreshTokenRevoked is returned if its family has been revoked
+	Save(token RefreshToken) error
+	// Use marks the token with id as used and returns it as it was before, it must be atomic so only one of several concurrent calls sees Used as false.
+	// ErrRefreshTokenNotFound is returned if there is no token with id and Revoked is true if its family has been revoked.
+	Use(id string) (token RefreshToken, err error)
+	// RevokeFamily revokes a family, the tokens in it and the tokens saved into it later
+	RevokeFamily(familyID string) error
+}
+
+// RefreshTokenIssuer issues refresh tokens and rotates them, a refresh token can only be used once and is exchanged for a new one every time
+type RefreshTokenIssuer struct {
+	store    RefreshTokenStore
+	lifetime time.Duration
+}
+
+// NewRefreshTokenIssuer creates a RefreshTokenIssuer that keeps the tokens in store, a lifetime of 0 means DefaultRefreshTokenLifetime
+func NewRefreshTokenIssuer(store RefreshTokenStore, lifetime time.Duration) *RefreshTokenIssuer {
+	if lifetime == 0 {
+		lifetime = DefaultRefreshTokenLifetime
+	}
+
+	return &RefreshTokenIssuer{store: store, lifetime: lifetime}
+}
+
+// Issue creates a refresh token in a new family for an account, e.g. when the account logs in
+func (issuer *RefreshTokenIssuer) Issue(accountID string) (refreshToken string, err error) {
+	familyID, err := newRandomToken()
+	if err != nil {
+		return
+	}
+
+	refreshToken, err = issuer.issue(accountID, familyID)
+
+	return
+}
+
+// Rotate exchanges a refresh token for a new one in the same family and returns the ID of the account so a new access token can be generated for it.
+// Using a token that has already been rotated revokes the whole family and returns ErrRefreshTokenReused.
+func (issuer *RefreshTokenIssuer) Rotate(refreshToken string) (accountID string, nextRefreshToken string, err error) {
+	token, err := issuer.store.Use(hashRefreshToken(refreshToken))
+	if err != nil {
+		return
+	}
+
+	if token.Revoked {
+		err = ErrRefreshTokenRevoked
+		return
+	}
+
+	if token.Used {
+		err = issuer.store.RevokeFamily(token.FamilyID)
+		if err == nil {
+			err = ErrRefreshTokenReused
+		}
+		return
+	}
+
+	if !time.Now().Before(token.ExpiresAt) {
+		err = errors.New("The refresh token has expired")
+		return
+	}
+
+	nextRefreshToken, err = issuer.issue(token.AccountID, token.FamilyID)
+	if err != nil {
+		return
+	}
+
+	accountID = token.AccountID
+
+	return
+}
+
+// Revoke revokes the family of a refresh token, e.g. when the account logs out
+func (issuer *RefreshTokenIssuer) Revoke(refreshToken string) (err error) {
+	token, err := issuer.store.Use(hashRefreshToken(refreshToken))
+	if err != nil {
+		return
+	}
+
+	err = issuer.store.RevokeFamily(token.FamilyID)
+
+	return
+}
+
+func (issuer *RefreshTokenIssuer) issue(accountID string, familyID string) (refreshToken string, err error) {
+	refreshToken, err = newRandomToken()
+	if err != nil {
+		return
+	}
+
+	err = issuer.store.Save(RefreshToken{
+		ID:        hashRefreshToken(refreshToken),
+		FamilyID:  familyID,
+		AccountID: accountID,
+		ExpiresAt: time.Now().Add(issuer.lifetime),
+	})
+	if err != nil {
+		refreshToken = ""
+	}
+
+	return
+}
+
+// GenerateWithRefreshToken generates a token like Generate together with a refresh token in a new family from refreshTokens
+func GenerateWithRefreshToken(issuer string, privateKey gocrypto.Signer, account Account, refreshTokens *RefreshTokenIssuer) (accessToken []byte, refreshToken string, err error) {
+	accessToken, refreshToken, err = refreshTokens.withAccessToken(account, func() ([]byte, error) {
+		return Generate(issuer, privateKey, account)
+	})
+
+	return
+}
+
+// GenerateWithRefreshToken generates a token signed with the active key together with a refresh token in a new family from refreshTokens
+func (issuer *Issuer) GenerateWithRefreshToken(account Account, refreshTokens *RefreshTokenIssuer) (accessToken []byte, refreshToken string, err error) {
+	accessToken, refreshToken, err = refreshTokens.withAccessToken(account, func() ([]byte, error) {
+		return issuer.Generate(account)
+	})
+
+	return
+}
+
+// withAccessToken issues a refresh token for account after generating its access token, neither is returned if one of them fails
+func (issuer *RefreshTokenIssuer) withAccessToken(account Account, generate func() ([]byte, error)) (accessToken []byte, refreshToken string, err error) {
+	accessToken, err = generate()
+	if err != nil {
+		return
+	}
+
+	refreshToken, err = issuer.Issue(account.GetID())
+	if err != nil {
+		accessToken = nil
+	}
+
+	return
+}
+
+func newRandomToken() (token string, err error) {
+	data := make([]byte, 32)
+
+	_, err = rand.Read(data)
+	if err != nil {
+		return
+	}
+
+	token = base64.RawURLEncoding.EncodeToString(data)
+
+	return
+}
+
+func hashRefreshToken(refreshToken string) string {
+	checksum := sha256.Sum256([]byte(refreshToken))
+	return hex.EncodeToString(checksum[:])
+}
@@ -0,0 +1,189 @@
+package jwt_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mojlighetsministeriet/utils/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateWithRefreshTokenAndRotate(test *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(test, err)
+
+	refreshTokens := jwt.NewRefreshTokenIssuer(jwt.NewMemoryRefreshTokenStore(), 0)
+
+	accessToken, refreshToken, err := jwt.GenerateWithRefreshToken("test-issuer", privateKey, newTestAccount(), refreshTokens)
+	assert.NoError(test, err)
+	assert.NotEmpty(test, refreshToken)
+
+	_, err = jwt.ParseIfValid(privateKey.Public(), accessToken)
+	assert.NoError(test, err)
+
+	accountID, nextRefreshToken, err := refreshTokens.Rotate(refreshToken)
+	assert.NoError(test, err)
+	assert.Equal(test, "a-user", accountID)
+	assert.NotEmpty(test, nextRefreshToken)
+	assert.NotEqual(test, refreshToken, nextRefreshToken)
+
+	accountID, _, err = refreshTokens.Rotate(nextRefreshToken)
+	assert.NoError(test, err)
+	assert.Equal(test, "a-user", accountID)
+}
+
+func TestIssuerGenerateWithRefreshToken(test *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(test, err)
+
+	issuer, err := jwt.NewIssuer("test-issuer", jwt.SigningKey{ID: "first", Key: privateKey})
+	assert.NoError(test, err)
+
+	accessToken, refreshToken, err := issuer.GenerateWithRefreshToken(newTestAccount(), jwt.NewRefreshTokenIssuer(jwt.NewMemoryRefreshTokenStore(), time.Hour))
+	assert.NoError(test, err)
+	assert.NotEmpty(test, refreshToken)
+
+	_, err = jwt.ParseIfValid(issuer, accessToken)
+	assert.NoError(test, err)
+}
+
+func TestRefreshTokenReuseRevokesFamily(test *testing.T) {
+	refreshTokens := jwt.NewRefreshTokenIssuer(jwt.NewMemoryRefreshTokenStore(), time.Hour)
+
+	stolenRefreshToken, err := refreshTokens.Issue("a-user")
+	assert.NoError(test, err)
+
+	otherFamilyRefreshToken, err := refreshTokens.Issue("a-user")
+	assert.NoError(test, err)
+
+	_, nextRefreshToken, err := refreshTokens.Rotate(stolenRefreshToken)
+	assert.NoError(test, err)
+
+	_, _, err = refreshTokens.Rotate(stolenRefreshToken)
+	assert.Equal(test, jwt.ErrRefreshTokenReused, err)
+
+	_, _, err = refreshTokens.Rotate(nextRefreshToken)
+	assert.Error(test, err)
+	assert.Equal(test, "The refresh token has been revoked", err.Error())
+
+	_, _, err = refreshTokens.Rotate(otherFamilyRefreshToken)
+	assert.NoError(test, err)
+}
+
+func TestRefreshTokenConcurrentRotationOnlySucceedsOnce(test *testing.T) {
+	refreshTokens := jwt.NewRefreshTokenIssuer(jwt.NewMemoryRefreshTokenStore(), time.Hour)
+
+	refreshToken, err := refreshTokens.Issue("a-user")
+	assert.NoError(test, err)
+
+	var waitGroup sync.WaitGroup
+	results := make(chan error, 10)
+
+	for i := 0; i < 10; i++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			_, _, rotateErr := refreshTokens.Rotate(refreshToken)
+			results <- rotateErr
+		}()
+	}
+
+	waitGroup.Wait()
+	close(results)
+
+	succeeded := 0
+	for result := range results {
+		if result == nil {
+			succeeded++
+		}
+	}
+
+	assert.Equal(test, 1, succeeded)
+}
+
+func TestRevokeRefreshToken(test *testing.T) {
+	refreshTokens := jwt.NewRefreshTokenIssuer(jwt.NewMemoryRefreshTokenStore(), time.Hour)
+
+	refreshToken, err := refreshTokens.Issue("a-user")
+	assert.NoError(test, err)
+
+	_, nextRefreshToken, err := refreshTokens.Rotate(refreshToken)
+	assert.NoError(test, err)
+
+	err = refreshTokens.Revoke(nextRefreshToken)
+	assert.NoError(test, err)
+
+	_, _, err = refreshTokens.Rotate(nextRefreshToken)
+	assert.Error(test, err)
+	assert.Equal(test, "The refresh token has been revoked", err.Error())
+}
+
+// revokingRefreshTokenStore revokes the family of a token right after it is used, as if the account logged out while the token was being rotated
+type revokingRefreshTokenStore struct {
+	*jwt.MemoryRefreshTokenStore
+}
+
+func (store revokingRefreshTokenStore) Use(id string) (token jwt.RefreshToken, err error) {
+	token, err = store.MemoryRefreshTokenStore.Use(id)
+	if err == nil {
+		err = store.RevokeFamily(token.FamilyID)
+	}
+
+	return
+}
+
+func TestRotateRefreshTokenInFamilyRevokedMeanwhile(test *testing.T) {
+	refreshTokens := jwt.NewRefreshTokenIssuer(revokingRefreshTokenStore{jwt.NewMemoryRefreshTokenStore()}, time.Hour)
+
+	refreshToken, err := refreshTokens.Issue("a-user")
+	assert.NoError(test, err)
+
+	accountID, nextRefreshToken, err := refreshTokens.Rotate(refreshToken)
+	assert.Equal(test, jwt.ErrRefreshTokenRevoked, err)
+	assert.Equal(test, "", accountID)
+	assert.Equal(test, "", nextRefreshToken)
+}
+
+func TestMemoryRefreshTokenStoreRemembersRevokedFamilies(test *testing.T) {
+	store := jwt.NewMemoryRefreshTokenStore()
+	expiresAt := time.Now().Add(time.Hour)
+
+	err := store.Save(jwt.RefreshToken{ID: "first", FamilyID: "a-family", AccountID: "a-user", ExpiresAt: expiresAt})
+	assert.NoError(test, err)
+
+	err = store.RevokeFamily("a-family")
+	assert.NoError(test, err)
+
+	err = store.Save(jwt.RefreshToken{ID: "second", FamilyID: "a-family", AccountID: "a-user", ExpiresAt: expiresAt})
+	assert.Equal(test, jwt.ErrRefreshTokenRevoked, err)
+
+	_, err = store.Use("second")
+	assert.Equal(test, jwt.ErrRefreshTokenNotFound, err)
+
+	token, err := store.Use("first")
+	assert.NoError(test, err)
+	assert.Equal(test, true, token.Revoked)
+
+	err = store.Save(jwt.RefreshToken{ID: "other", FamilyID: "another-family", AccountID: "a-user", ExpiresAt: expiresAt})
+	assert.NoError(test, err)
+}
+
+func TestFailRotateRefreshToken(test *testing.T) {
+	refreshTokens := jwt.NewRefreshTokenIssuer(jwt.NewMemoryRefreshTokenStore(), 10*time.Millisecond)
+
+	_, _, err := refreshTokens.Rotate("unknown")
+	assert.Equal(test, jwt.ErrRefreshTokenNotFound, err)
+
+	refreshToken, err := refreshTokens.Issue("a-user")
+	assert.NoError(test, err)
+
+	time.Sleep(20 * time.Millisecond)
+
+	_, _, err = refreshTokens.Rotate(refreshToken)
+	assert.Error(test, err)
+	assert.Equal(test, "The refresh token has expired", err.Error())
+}
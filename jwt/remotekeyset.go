@@ -0,0 +1,242 @@
+package jwt
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultMinimumRefreshInterval is the shortest time between two fetches caused by tokens with an unknown kid
+const DefaultMinimumRefreshInterval = time.Minute
+
+// MaximumKeySetSize is the largest key set or public key, in bytes, that a RemoteKeySet or LoadPublicKeyFromURL reads
+const MaximumKeySetSize = 1 << 20
+
+// RemoteKeySet is a KeySet that fetches a JSON Web Key Set, or PEM encoded public keys, from a URL and keeps it up to date.
+// The keys are fetched again every refresh interval and when a token has an unknown kid, but not more often than the minimum refresh interval.
+type RemoteKeySet struct {
+	url                    string
+	client                 *http.Client
+	mutex                  sync.RWMutex
+	keys                   VerificationKeys
+	fetchMutex             sync.Mutex
+	currentFetch           *keySetFetch
+	lastFetch              time.Time
+	minimumRefreshInterval time.Duration
+	stop                   chan struct{}
+	stopOnce               sync.Once
+}
+
+// keySetFetch is a fetch in progress that concurrent refreshes wait for instead of fetching the keys again
+type keySetFetch struct {
+	done chan struct{}
+	err  error
+}
+
+// NewRemoteKeySet fetches the keys at url with a 10 second timeout and refetches them every refreshInterval, a refreshInterval of 0 only refetches on unknown kid
+func NewRemoteKeySet(url string, refreshInterval time.Duration) (*RemoteKeySet, error) {
+	return NewRemoteKeySetWithClient(url, refreshInterval, &http.Client{Timeout: 10 * time.Second})
+}
+
+// NewRemoteKeySetWithClient works like NewRemoteKeySet but uses a custom http client e.g. from httprequest.NewClientWithTLSConfig for an internal CA
+func NewRemoteKeySetWithClient(url string, refreshInterval time.Duration, client *http.Client) (keySet *RemoteKeySet, err error) {
+	result := &RemoteKeySet{
+		url:                    url,
+		client:                 client,
+		minimumRefreshInterval: DefaultMinimumRefreshInterval,
+		stop:                   make(chan struct{}),
+	}
+
+	err = result.Refresh()
+	if err != nil {
+		return
+	}
+
+	if refreshInterval > 0 {
+		go result.poll(refreshInterval)
+	}
+
+	keySet = result
+
+	return
+}
+
+// SetMinimumRefreshInterval changes how often tokens with an unknown kid can cause the keys to be fetched, the default is DefaultMinimumRefreshInterval
+func (keySet *RemoteKeySet) SetMinimumRefreshInterval(interval time.Duration) {
+	keySet.fetchMutex.Lock()
+	defer keySet.fetchMutex.Unlock()
+
+	keySet.minimumRefreshInterval = interval
+}
+
+// VerificationKeys returns the current keys, it makes a RemoteKeySet a VerificationKeyProvider so the keys can be republished
+func (keySet *RemoteKeySet) VerificationKeys() []VerificationKey {
+	keySet.mutex.RLock()
+	defer keySet.mutex.RUnlock()
+
+	return append([]VerificationKey(nil), keySet.keys...)
+}
+
+// GetVerificationKey returns the key with keyID, the keys are fetched again if there is no such key and they have not been fetched within the minimum refresh interval
+func (keySet *RemoteKeySet) GetVerificationKey(keyID string) (key VerificationKey, err error) {
+	key, err = VerificationKeys(keySet.VerificationKeys()).GetVerificationKey(keyID)
+	if err == nil || keyID == "" {
+		return
+	}
+
+	refreshed, refreshErr := keySet.refresh(false)
+	if refreshErr != nil {
+		err = refreshErr
+		return
+	}
+
+	if refreshed {
+		key, err = VerificationKeys(keySet.VerificationKeys()).GetVerificationKey(keyID)
+	}
+
+	return
+}
+
+// Refresh fetches the keys now, or waits for a fetch that is already in progress, the previous keys are kept if they can not be fetched
+func (keySet *RemoteKeySet) Refresh() (err error) {
+	_, err = keySet.refresh(true)
+	return
+}
+
+// Close stops refreshing the keys in the background
+func (keySet *RemoteKeySet) Close() {
+	keySet.stopOnce.Do(func() {
+		close(keySet.stop)
+	})
+}
+
+// refresh fetches the keys unless they have been fetched within the minimum refresh interval and force is false. Only one fetch is made at a time,
+// the fetch mutex is not held during the request so callers that arrive meanwhile wait for the same fetch without blocking each other.
+func (keySet *RemoteKeySet) refresh(force bool) (refreshed bool, err error) {
+	keySet.fetchMutex.Lock()
+
+	current := keySet.currentFetch
+	if current == nil {
+		if !force && time.Since(keySet.lastFetch) < keySet.minimumRefreshInterval {
+			keySet.fetchMutex.Unlock()
+			return
+		}
+
+		current = &keySetFetch{done: make(chan struct{})}
+		keySet.currentFetch = current
+		keySet.lastFetch = time.Now()
+		keySet.fetchMutex.Unlock()
+
+		current.err = keySet.fetch()
+
+		keySet.fetchMutex.Lock()
+		keySet.currentFetch = nil
+		keySet.fetchMutex.Unlock()
+		close(current.done)
+	} else {
+		keySet.fetchMutex.Unlock()
+		<-current.done
+	}
+
+	err = current.err
+	refreshed = err == nil
+
+	return
+}
+
+func (keySet *RemoteKeySet) fetch() (err error) {
+	response, err := keySet.client.Get(keySet.url)
+	if err != nil {
+		return
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		err = errors.New("Failed to fetch keys from " + keySet.url + ": " + response.Status)
+		return
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(response.Body, MaximumKeySetSize+1))
+	if err != nil {
+		return
+	}
+
+	if len(body) > MaximumKeySetSize {
+		err = errors.New("The keys from " + keySet.url + " are larger than " + strconv.Itoa(MaximumKeySetSize) + " bytes")
+		return
+	}
+
+	keys, err := parseKeySet(body)
+	if err != nil {
+		err = errors.New("Failed to parse keys from " + keySet.url + ": " + err.Error())
+		return
+	}
+
+	keySet.mutex.Lock()
+	keySet.keys = keys
+	keySet.mutex.Unlock()
+
+	return
+}
+
+func (keySet *RemoteKeySet) poll(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			// The previous keys are kept while the endpoint is unavailable
+			keySet.Refresh()
+		case <-keySet.stop:
+			return
+		}
+	}
+}
+
+// parseKeySet parses a JSON Web Key Set, keys with unsupported types are skipped, or PEM encoded public keys
+func parseKeySet(data []byte) (keys VerificationKeys, err error) {
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		publicKeys, pemErr := parsePEMPublicKeys(data)
+		if pemErr != nil {
+			err = pemErr
+			return
+		}
+
+		for _, publicKey := range publicKeys {
+			keys = append(keys, VerificationKey{Key: publicKey})
+		}
+
+		return
+	}
+
+	keySet := JSONWebKeySet{}
+	err = json.Unmarshal(data, &keySet)
+	if err != nil {
+		return
+	}
+
+	for _, webKey := range keySet.Keys {
+		if webKey.Use != "" && webKey.Use != "sig" {
+			continue
+		}
+
+		key, keyErr := webKey.VerificationKey()
+		if keyErr == nil {
+			keys = append(keys, key)
+		}
+	}
+
+	if len(keys) == 0 {
+		err = errors.New("None of the " + strconv.Itoa(len(keySet.Keys)) + " keys are supported")
+	}
+
+	return
+}
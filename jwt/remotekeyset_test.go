@@ -0,0 +1,257 @@
+package jwt_test
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/SermoDigital/jose/crypto"
+	"github.com/SermoDigital/jose/jws"
+	"github.com/labstack/echo"
+	"github.com/mojlighetsministeriet/utils/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func createTokenWithKeyID(test *testing.T, privateKey *ecdsa.PrivateKey, keyID string) []byte {
+	claims := jws.Claims{}
+	claims.SetSubject("an-account")
+	claims.Set("roles", "administrator")
+	claims.SetExpiration(time.Now().Add(time.Minute))
+
+	token := jws.NewJWT(claims, crypto.SigningMethodES256)
+	token.(jws.JWS).Protected().Set("kid", keyID)
+
+	serializedToken, err := token.Serialize(privateKey)
+	assert.NoError(test, err)
+
+	return serializedToken
+}
+
+type keySetServer struct {
+	*httptest.Server
+	mutex    sync.Mutex
+	keys     jwt.VerificationKeys
+	requests int32
+}
+
+func (server *keySetServer) setKeys(keys ...jwt.VerificationKey) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	server.keys = keys
+}
+
+func startKeySetServer(test *testing.T, keys ...jwt.VerificationKey) *keySetServer {
+	server := &keySetServer{keys: keys}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		atomic.AddInt32(&server.requests, 1)
+		server.mutex.Lock()
+		defer server.mutex.Unlock()
+		keySet, err := jwt.NewJSONWebKeySet(server.keys)
+		assert.NoError(test, err)
+		json.NewEncoder(writer).Encode(keySet)
+	}))
+
+	return server
+}
+
+func TestRemoteKeySet(test *testing.T) {
+	firstKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(test, err)
+
+	secondKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(test, err)
+
+	server := startKeySetServer(test, jwt.VerificationKey{ID: "first", Key: firstKey.Public()})
+	defer server.Close()
+
+	keySet, err := jwt.NewRemoteKeySet(server.URL, 0)
+	assert.NoError(test, err)
+	defer keySet.Close()
+	keySet.SetMinimumRefreshInterval(0)
+
+	parsedToken, err := jwt.ParseIfValid(keySet, createTokenWithKeyID(test, firstKey, "first"))
+	assert.NoError(test, err)
+	assert.Equal(test, "an-account", parsedToken.Claims().Get("sub"))
+	assert.Equal(test, int32(1), atomic.LoadInt32(&server.requests))
+
+	// A rotated key is fetched when a token with an unknown kid arrives
+	server.setKeys(jwt.VerificationKey{ID: "first", Key: firstKey.Public()}, jwt.VerificationKey{ID: "second", Key: secondKey.Public()})
+
+	parsedToken, err = jwt.ParseIfValid(keySet, createTokenWithKeyID(test, secondKey, "second"))
+	assert.NoError(test, err)
+	assert.Equal(test, "an-account", parsedToken.Claims().Get("sub"))
+	assert.Equal(test, int32(2), atomic.LoadInt32(&server.requests))
+	assert.Equal(test, 2, len(keySet.VerificationKeys()))
+
+	// The wrong key is never used even if the kid is known
+	_, err = jwt.ParseIfValid(keySet, createTokenWithKeyID(test, secondKey, "first"))
+	assert.Error(test, err)
+}
+
+func TestRemoteKeySetRateLimitsUnknownKeyIDs(test *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(test, err)
+
+	server := startKeySetServer(test, jwt.VerificationKey{ID: "first", Key: privateKey.Public()})
+	defer server.Close()
+
+	keySet, err := jwt.NewRemoteKeySet(server.URL, 0)
+	assert.NoError(test, err)
+	defer keySet.Close()
+
+	for i := 0; i < 10; i++ {
+		_, err = jwt.ParseIfValid(keySet, createTokenWithKeyID(test, privateKey, "unknown"))
+		assert.Error(test, err)
+		assert.Equal(test, "There is no key with kid unknown", err.Error())
+	}
+
+	assert.Equal(test, int32(1), atomic.LoadInt32(&server.requests))
+}
+
+func TestRemoteKeySetSharesConcurrentFetches(test *testing.T) {
+	firstKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(test, err)
+
+	secondKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(test, err)
+
+	server := startKeySetServer(test, jwt.VerificationKey{ID: "first", Key: firstKey.Public()})
+	defer server.Close()
+
+	keySet, err := jwt.NewRemoteKeySet(server.URL, 0)
+	assert.NoError(test, err)
+	defer keySet.Close()
+	keySet.SetMinimumRefreshInterval(0)
+
+	// The server answers once the test unlocks its mutex, all requests for the new kid meanwhile wait for the same fetch
+	server.mutex.Lock()
+
+	results := make(chan error, 5)
+	for i := 0; i < cap(results); i++ {
+		go func() {
+			_, keyErr := keySet.GetVerificationKey("second")
+			results <- keyErr
+		}()
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for atomic.LoadInt32(&server.requests) < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	server.keys = jwt.VerificationKeys{{ID: "second", Key: secondKey.Public()}}
+	server.mutex.Unlock()
+
+	for i := 0; i < cap(results); i++ {
+		assert.NoError(test, <-results)
+	}
+
+	assert.Equal(test, int32(2), atomic.LoadInt32(&server.requests))
+}
+
+func TestRemoteKeySetRefreshesInTheBackground(test *testing.T) {
+	firstKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(test, err)
+
+	secondKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(test, err)
+
+	server := startKeySetServer(test, jwt.VerificationKey{ID: "first", Key: firstKey.Public()})
+	defer server.Close()
+
+	keySet, err := jwt.NewRemoteKeySet(server.URL, 10*time.Millisecond)
+	assert.NoError(test, err)
+	defer keySet.Close()
+
+	server.setKeys(jwt.VerificationKey{ID: "second", Key: secondKey.Public()})
+
+	keys := keySet.VerificationKeys()
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline) && keys[0].ID != "second"; keys = keySet.VerificationKeys() {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	assert.Equal(test, 1, len(keys))
+	assert.Equal(test, "second", keys[0].ID)
+}
+
+func TestRemoteKeySetWithPEM(test *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(test, err)
+
+	publicKey, err := x509.MarshalPKIXPublicKey(privateKey.Public())
+	assert.NoError(test, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Write(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKey}))
+	}))
+	defer server.Close()
+
+	keySet, err := jwt.NewRemoteKeySet(server.URL, 0)
+	assert.NoError(test, err)
+	defer keySet.Close()
+
+	parsedToken, err := jwt.ParseIfValid(keySet, createTokenWithKeyID(test, privateKey, ""))
+	assert.NoError(test, err)
+	assert.Equal(test, "an-account", parsedToken.Claims().Get("sub"))
+}
+
+func TestRequiredRoleMiddlewareWithRemoteKeySet(test *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(test, err)
+
+	server := startKeySetServer(test, jwt.VerificationKey{ID: "first", Key: privateKey.Public()})
+	defer server.Close()
+
+	keySet, err := jwt.NewRemoteKeySet(server.URL, 0)
+	assert.NoError(test, err)
+	defer keySet.Close()
+
+	request := httptest.NewRequest(echo.GET, "/", nil)
+	request.Header.Set("Authorization", "Bearer "+string(createTokenWithKeyID(test, privateKey, "first")))
+	recorder := httptest.NewRecorder()
+	context := echo.New().NewContext(request, recorder)
+
+	err = jwt.RequiredRoleMiddleware(keySet, "administrator")(func(context echo.Context) error {
+		return context.NoContent(http.StatusOK)
+	})(context)
+	assert.NoError(test, err)
+	assert.Equal(test, http.StatusOK, recorder.Code)
+}
+
+func TestFailNewRemoteKeySet(test *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Write([]byte("{\"keys\":[{\"kty\":\"oct\",\"k\":\"c2VjcmV0\"}]}"))
+	}))
+	defer server.Close()
+
+	_, err := jwt.NewRemoteKeySet(server.URL, 0)
+	assert.Error(test, err)
+	assert.Equal(test, "Failed to parse keys from "+server.URL+": None of the 1 keys are supported", err.Error())
+
+	notFoundServer := httptest.NewServer(http.NotFoundHandler())
+	defer notFoundServer.Close()
+
+	_, err = jwt.NewRemoteKeySet(notFoundServer.URL, 0)
+	assert.Error(test, err)
+	assert.Equal(test, "Failed to fetch keys from "+notFoundServer.URL+": 404 Not Found", err.Error())
+
+	largeServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Write(bytes.Repeat([]byte(" "), jwt.MaximumKeySetSize+1))
+	}))
+	defer largeServer.Close()
+
+	_, err = jwt.NewRemoteKeySet(largeServer.URL, 0)
+	assert.Error(test, err)
+	assert.Equal(test, "The keys from "+largeServer.URL+" are larger than 1048576 bytes", err.Error())
+}
@@ -1,7 +1,8 @@
 package jwt
 
 import (
-	"crypto/rsa"
+	gocrypto "crypto"
+	"errors"
 	"strings"
 	"time"
 
@@ -19,8 +20,31 @@ type Account interface {
 	GetRolesSerialized() string
 }
 
-// GenerateWithCustomExpiration generates a new JWT token from an account with a custom expiration time
-func GenerateWithCustomExpiration(issuer string, privateKey *rsa.PrivateKey, account Account, expiration time.Time) (serializedToken []byte, err error) {
+// GenerateWithCustomExpiration generates a new JWT token from an account with a custom expiration time, the algorithm is chosen from the key type (see DefaultAlgorithm)
+func GenerateWithCustomExpiration(issuer string, privateKey gocrypto.Signer, account Account, expiration time.Time) (serializedToken []byte, err error) {
+	algorithm, err := DefaultAlgorithm(privateKey.Public())
+	if err != nil {
+		return
+	}
+
+	serializedToken, err = GenerateWithAlgorithm(issuer, algorithm, privateKey, account, expiration)
+
+	return
+}
+
+// GenerateWithAlgorithm generates a new JWT token from an account signed with a specific algorithm, e.g. PS256 for an RSA key
+func GenerateWithAlgorithm(issuer string, algorithm Algorithm, privateKey gocrypto.Signer, account Account, expiration time.Time) (serializedToken []byte, err error) {
+	serializedToken, err = generate(issuer, SigningKey{Algorithm: algorithm, Key: privateKey}, account, expiration)
+	return
+}
+
+// generate signs a token with key, the kid header is only set when the key has an ID
+func generate(issuer string, key SigningKey, account Account, expiration time.Time) (serializedToken []byte, err error) {
+	method, err := getSigningMethod(key.Algorithm)
+	if err != nil {
+		return
+	}
+
 	claims := jws.Claims{}
 
 	claims.SetExpiration(expiration)
@@ -29,35 +53,107 @@ func GenerateWithCustomExpiration(issuer string, privateKey *rsa.PrivateKey, acc
 	claims.Set("email", account.GetEmail())
 	claims.Set("roles", account.GetRolesSerialized())
 
-	token := jws.NewJWT(claims, crypto.SigningMethodRS256)
+	token := jws.NewJWT(claims, method)
+	if key.ID != "" {
+		token.(jws.JWS).Protected().Set("kid", key.ID)
+	}
 
-	serializedToken, err = token.Serialize(privateKey)
+	serializedToken, err = token.Serialize(key.Key)
 
 	return
 }
 
 // Generate a new JWT token from an account
-func Generate(issuer string, privateKey *rsa.PrivateKey, account Account) ([]byte, error) {
+func Generate(issuer string, privateKey gocrypto.Signer, account Account) ([]byte, error) {
 	return GenerateWithCustomExpiration(issuer, privateKey, account, time.Now().Add(time.Duration(60*20)*time.Second))
 }
 
-// ParseIfValid return a parsed JWT token if it is valid
-func ParseIfValid(publicKey *rsa.PublicKey, tokenData []byte) (token josejwt.JWT, err error) {
+// ParseIfValid return a parsed JWT token if it is valid, only the algorithms that can be used with the type of publicKey are accepted.
+// publicKey can also be a KeySet, such as a RemoteKeySet, and the key is then selected by the kid header of the token.
+func ParseIfValid(publicKey gocrypto.PublicKey, tokenData []byte) (token josejwt.JWT, err error) {
+	token, err = ParseIfValidWithAlgorithms(publicKey, tokenData)
+	return
+}
+
+// ParseIfValidWithAlgorithms return a parsed JWT token if it is valid and signed with one of the allowed algorithms,
+// restricting the algorithms prevents a token from being verified with a key that was meant for another algorithm.
+// Without algorithms the alg of the key, or the algorithms that can be used with the type of key, are allowed.
+func ParseIfValidWithAlgorithms(publicKey gocrypto.PublicKey, tokenData []byte, algorithms ...Algorithm) (token josejwt.JWT, err error) {
 	token, err = jws.ParseJWT(tokenData)
 	if err != nil {
 		return
 	}
 
-	err = token.Validate(publicKey, crypto.SigningMethodRS256)
+	err = validate(token, publicKey, algorithms)
 	if err != nil {
-		claims := jws.Claims{}
-		claims.SetExpiration(time.Now().Add(time.Duration(60*20) * time.Second))
-		token = jws.NewJWT(claims, crypto.SigningMethodRS256)
+		token = newExpiringToken()
 	}
 
 	return
 }
 
+func validate(token josejwt.JWT, publicKey gocrypto.PublicKey, algorithms []Algorithm) (err error) {
+	signedToken, ok := token.(jws.JWS)
+	if !ok {
+		err = errors.New("The token is not signed")
+		return
+	}
+
+	key := VerificationKey{Key: publicKey}
+	if keySet, isKeySet := publicKey.(KeySet); isKeySet {
+		keyID, _ := signedToken.Protected().Get("kid").(string)
+		key, err = keySet.GetVerificationKey(keyID)
+		if err != nil {
+			return
+		}
+	}
+
+	if len(algorithms) == 0 {
+		if key.Algorithm != "" {
+			algorithms = []Algorithm{key.Algorithm}
+		} else {
+			algorithms, err = algorithmsForKey(key.Key)
+			if err != nil {
+				return
+			}
+		}
+	}
+
+	name, _ := signedToken.Protected().Get("alg").(string)
+	algorithm := Algorithm(name)
+
+	if !isAllowedAlgorithm(algorithm, algorithms) || (key.Algorithm != "" && key.Algorithm != algorithm) {
+		err = errors.New("The algorithm " + name + " is not allowed")
+		return
+	}
+
+	method, err := getSigningMethod(algorithm)
+	if err != nil {
+		return
+	}
+
+	err = token.Validate(key.Key, method)
+
+	return
+}
+
+func isAllowedAlgorithm(algorithm Algorithm, algorithms []Algorithm) bool {
+	for _, allowed := range algorithms {
+		if allowed == algorithm {
+			return true
+		}
+	}
+
+	return false
+}
+
+// newExpiringToken returns the empty token that ParseIfValid has always returned together with an error
+func newExpiringToken() josejwt.JWT {
+	claims := jws.Claims{}
+	claims.SetExpiration(time.Now().Add(time.Duration(60*20) * time.Second))
+	return jws.NewJWT(claims, crypto.SigningMethodRS256)
+}
+
 // GetTokenFromContext will extract the token bytes from the HTTP request header connected to a echo.Context object
 func GetTokenFromContext(context echo.Context) (result []byte) {
 	token := context.Request().Header.Get("Authorization")
@@ -72,7 +168,7 @@ func GetTokenFromContext(context echo.Context) (result []byte) {
 }
 
 // GetClaimsFromContextIfValid validates the JWT token and fetches the claims from the JWT
-func GetClaimsFromContextIfValid(publicKey *rsa.PublicKey, context echo.Context) (claims josejwt.Claims, err error) {
+func GetClaimsFromContextIfValid(publicKey gocrypto.PublicKey, context echo.Context) (claims josejwt.Claims, err error) {
 	token, err := ParseIfValid(publicKey, GetTokenFromContext(context))
 	if err != nil {
 		return
@@ -0,0 +1,256 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ConfigError describes a configuration field that could not be loaded
+type ConfigError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error is used to describe why a field could not be loaded
+func (err ConfigError) Error() string {
+	return "error: " + err.Message + " for field " + err.Field
+}
+
+// ConfigErrors is an error containing every ConfigError found while loading a configuration
+type ConfigErrors []ConfigError
+
+// Error lists all the fields that could not be loaded, one per line
+func (configErrors ConfigErrors) Error() string {
+	buffer := bytes.NewBufferString("")
+
+	for i := 0; i < len(configErrors); i++ {
+		buffer.WriteString(configErrors[i].Error())
+		buffer.WriteString("\n")
+	}
+
+	return strings.TrimSpace(buffer.String())
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+var urlType = reflect.TypeOf(url.URL{})
+
+// LoadConfig fills a pointer to a struct from the struct tags env, file, default and required e.g.
+// Port int `env:"PORT" file:"/run/secrets/port" default:"8080" required:"true"`.
+// The environment variable is used first, then the file and last the default value. Every missing or
+// unparsable field is reported in the returned ConfigErrors. Fields tagged secret:"true" are masked by GetConfigRecords.
+func LoadConfig(config interface{}) (err error) {
+	value := reflect.ValueOf(config)
+	if value.Kind() != reflect.Ptr || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		err = errors.New("LoadConfig requires a pointer to a struct")
+		return
+	}
+
+	configErrors := loadConfigStruct(value.Elem(), "")
+	if len(configErrors) > 0 {
+		err = configErrors
+	}
+
+	return
+}
+
+func loadConfigStruct(structValue reflect.Value, path string) (configErrors ConfigErrors) {
+	structType := structValue.Type()
+
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		fieldValue := structValue.Field(i)
+		fieldPath := path + field.Name
+
+		if field.PkgPath != "" {
+			continue
+		}
+
+		if isNestedConfigStruct(field) {
+			configErrors = append(configErrors, loadConfigStruct(fieldValue, fieldPath+".")...)
+			continue
+		}
+
+		input, found := lookupConfigValue(field, fieldPath)
+		if !found {
+			if field.Tag.Get("required") == "true" {
+				configErrors = append(configErrors, ConfigError{Field: fieldPath, Message: "value is required but missing"})
+			}
+			continue
+		}
+
+		parseErr := setConfigValue(fieldValue, input)
+		if parseErr != nil {
+			configErrors = append(configErrors, ConfigError{Field: fieldPath, Message: parseErr.Error()})
+		}
+	}
+
+	return
+}
+
+func isNestedConfigStruct(field reflect.StructField) bool {
+	if field.Type.Kind() != reflect.Struct || field.Type == urlType {
+		return false
+	}
+
+	_, hasEnv := field.Tag.Lookup("env")
+	_, hasFile := field.Tag.Lookup("file")
+	_, hasDefault := field.Tag.Lookup("default")
+
+	return !hasEnv && !hasFile && !hasDefault
+}
+
+func lookupConfigValue(field reflect.StructField, fieldPath string) (value string, found bool) {
+	secret := field.Tag.Get("secret") == "true"
+	key := fieldPath
+
+	if envKey := field.Tag.Get("env"); envKey != "" {
+		var source string
+		key = envKey
+		value, source = lookupEnv(envKey)
+		if value != "" {
+			recordConfigValue(key, value, source, secret || source == ConfigSourceFile.String())
+			found = true
+			return
+		}
+	}
+
+	if path := field.Tag.Get("file"); path != "" {
+		value = readFileAsString(path)
+		if value != "" {
+			recordConfigValue(path, value, ConfigSourceFile.String(), true)
+			found = true
+			return
+		}
+	}
+
+	value, found = field.Tag.Lookup("default")
+	if found {
+		recordConfigValue(key, value, ConfigSourceDefault.String(), secret)
+	}
+
+	return
+}
+
+func setConfigValue(value reflect.Value, input string) (err error) {
+	switch {
+	case value.Type() == durationType:
+		var duration time.Duration
+		duration, err = time.ParseDuration(strings.TrimSpace(input))
+		if err != nil {
+			return errors.New("could not parse \"" + input + "\" as duration")
+		}
+		value.SetInt(int64(duration))
+		return
+
+	case value.Type() == urlType:
+		var parsedURL *url.URL
+		parsedURL, err = url.Parse(strings.TrimSpace(input))
+		if err != nil {
+			return errors.New("could not parse \"" + input + "\" as url")
+		}
+		value.Set(reflect.ValueOf(*parsedURL))
+		return
+
+	case value.Kind() == reflect.Ptr && value.Type().Elem() == urlType:
+		var parsedURL *url.URL
+		parsedURL, err = url.Parse(strings.TrimSpace(input))
+		if err != nil {
+			return errors.New("could not parse \"" + input + "\" as url")
+		}
+		value.Set(reflect.ValueOf(parsedURL))
+		return
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(input)
+
+	case reflect.Bool:
+		var parsed bool
+		parsed, err = strconv.ParseBool(strings.TrimSpace(input))
+		if err != nil {
+			return errors.New("could not parse \"" + input + "\" as bool")
+		}
+		value.SetBool(parsed)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var parsed int64
+		parsed, err = strconv.ParseInt(strings.TrimSpace(input), 10, value.Type().Bits())
+		if err != nil {
+			return errors.New("could not parse \"" + input + "\" as " + value.Kind().String())
+		}
+		value.SetInt(parsed)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var parsed uint64
+		parsed, err = strconv.ParseUint(strings.TrimSpace(input), 10, value.Type().Bits())
+		if err != nil {
+			return errors.New("could not parse \"" + input + "\" as " + value.Kind().String())
+		}
+		value.SetUint(parsed)
+
+	case reflect.Float32, reflect.Float64:
+		var parsed float64
+		parsed, err = strconv.ParseFloat(strings.TrimSpace(input), value.Type().Bits())
+		if err != nil {
+			return errors.New("could not parse \"" + input + "\" as " + value.Kind().String())
+		}
+		value.SetFloat(parsed)
+
+	case reflect.Slice:
+		items := splitConfigList(input)
+		slice := reflect.MakeSlice(value.Type(), len(items), len(items))
+		for i, item := range items {
+			err = setConfigValue(slice.Index(i), item)
+			if err != nil {
+				return
+			}
+		}
+		value.Set(slice)
+
+	case reflect.Map:
+		result := reflect.MakeMap(value.Type())
+		for _, item := range splitConfigList(input) {
+			pair := strings.SplitN(item, "=", 2)
+			if len(pair) != 2 {
+				return errors.New("could not parse \"" + item + "\" as key=value")
+			}
+
+			mapKey := reflect.New(value.Type().Key()).Elem()
+			err = setConfigValue(mapKey, strings.TrimSpace(pair[0]))
+			if err != nil {
+				return
+			}
+
+			mapValue := reflect.New(value.Type().Elem()).Elem()
+			err = setConfigValue(mapValue, strings.TrimSpace(pair[1]))
+			if err != nil {
+				return
+			}
+
+			result.SetMapIndex(mapKey, mapValue)
+		}
+		value.Set(result)
+
+	default:
+		err = errors.New("unsupported type " + value.Type().String())
+	}
+
+	return
+}
+
+func splitConfigList(input string) (items []string) {
+	for _, item := range strings.Split(input, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return
+}
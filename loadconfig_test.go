@@ -0,0 +1,90 @@
+package utils_test
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mojlighetsministeriet/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadConfig(test *testing.T) {
+	os.Setenv("LOAD_CONFIG_PORT", "9090")
+	os.Setenv("LOAD_CONFIG_DEBUG", "true")
+	os.Setenv("LOAD_CONFIG_HOSTS", "a.example.com, b.example.com")
+	os.Setenv("LOAD_CONFIG_TIMEOUT", "1m30s")
+	os.Setenv("LOAD_CONFIG_AUTH_URL", "https://auth.example.com/public-key")
+	defer os.Unsetenv("LOAD_CONFIG_PORT")
+	defer os.Unsetenv("LOAD_CONFIG_DEBUG")
+	defer os.Unsetenv("LOAD_CONFIG_HOSTS")
+	defer os.Unsetenv("LOAD_CONFIG_TIMEOUT")
+	defer os.Unsetenv("LOAD_CONFIG_AUTH_URL")
+
+	err := ioutil.WriteFile("/tmp/load-config-password", []byte("a secret\n"), 0600)
+	assert.NoError(test, err)
+	defer os.Remove("/tmp/load-config-password")
+
+	type SMTP struct {
+		Password string `env:"LOAD_CONFIG_SMTP_PASSWORD" file:"/tmp/load-config-password"`
+	}
+
+	type Config struct {
+		Port    int           `env:"LOAD_CONFIG_PORT" default:"8080"`
+		Debug   bool          `env:"LOAD_CONFIG_DEBUG"`
+		Hosts   []string      `env:"LOAD_CONFIG_HOSTS"`
+		Timeout time.Duration `env:"LOAD_CONFIG_TIMEOUT"`
+		AuthURL *url.URL      `env:"LOAD_CONFIG_AUTH_URL"`
+		Ratio   float64       `env:"LOAD_CONFIG_RATIO" default:"0.75"`
+		Ports   []int         `env:"LOAD_CONFIG_PORTS" default:"80,443"`
+		SMTP    SMTP
+	}
+
+	config := Config{}
+	err = utils.LoadConfig(&config)
+	assert.NoError(test, err)
+	assert.Equal(test, 9090, config.Port)
+	assert.Equal(test, true, config.Debug)
+	assert.Equal(test, []string{"a.example.com", "b.example.com"}, config.Hosts)
+	assert.Equal(test, 90*time.Second, config.Timeout)
+	assert.Equal(test, "auth.example.com", config.AuthURL.Host)
+	assert.Equal(test, 0.75, config.Ratio)
+	assert.Equal(test, []int{80, 443}, config.Ports)
+	assert.Equal(test, "a secret", config.SMTP.Password)
+}
+
+func TestFailLoadConfigWithMissingAndUnparsableValues(test *testing.T) {
+	os.Setenv("LOAD_CONFIG_BAD_PORT", "8080abc")
+	os.Setenv("LOAD_CONFIG_BAD_TIMEOUT", "soon")
+	defer os.Unsetenv("LOAD_CONFIG_BAD_PORT")
+	defer os.Unsetenv("LOAD_CONFIG_BAD_TIMEOUT")
+
+	type Config struct {
+		Port     int           `env:"LOAD_CONFIG_BAD_PORT" default:"8080"`
+		Timeout  time.Duration `env:"LOAD_CONFIG_BAD_TIMEOUT"`
+		Database string        `env:"LOAD_CONFIG_MISSING_DATABASE" required:"true"`
+	}
+
+	config := Config{}
+	err := utils.LoadConfig(&config)
+	assert.Error(test, err)
+
+	configErrors := err.(utils.ConfigErrors)
+	assert.Equal(test, 3, len(configErrors))
+	assert.Equal(test, "Port", configErrors[0].Field)
+	assert.Equal(test, "Timeout", configErrors[1].Field)
+	assert.Equal(test, "Database", configErrors[2].Field)
+	assert.Equal(test, "error: value is required but missing for field Database", configErrors[2].Error())
+}
+
+func TestFailLoadConfigWithNonPointer(test *testing.T) {
+	type Config struct {
+		Port int `env:"LOAD_CONFIG_PORT"`
+	}
+
+	err := utils.LoadConfig(Config{})
+	assert.Error(test, err)
+	assert.Equal(test, "LoadConfig requires a pointer to a struct", err.Error())
+}
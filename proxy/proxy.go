@@ -1,11 +1,14 @@
 package proxy
 
 import (
+	"crypto/tls"
 	"net"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/labstack/echo"
+	"github.com/mojlighetsministeriet/utils"
 )
 
 func setResponseHeaders(destination, source http.Header) {
@@ -30,27 +33,53 @@ func setProxyRequestHeaders(destination http.Header, sourceContext echo.Context)
 
 	destination.Del("Content-Length")
 
-	destination.Add("X-Forwarded-For", sourceContext.RealIP())
+	// Forwarded headers are only passed on from trusted proxies, the address of the peer is appended so the next service can follow the same trust chain
+	request := sourceContext.Request()
+	if !utils.IsTrustedProxy(request.RemoteAddr) {
+		for _, header := range utils.ForwardedHeaders {
+			destination.Del(header)
+		}
+	}
+
+	peer, _, err := net.SplitHostPort(request.RemoteAddr)
+	if err != nil {
+		peer = request.RemoteAddr
+	}
+
+	forwardedFor := append(destination["X-Forwarded-For"], peer)
+	destination.Set("X-Forwarded-For", strings.Join(forwardedFor, ", "))
 
 	if destination.Get("X-Forwarded-Host") == "" {
-		destination.Set("X-Forwarded-Host", sourceContext.Request().Host)
+		destination.Set("X-Forwarded-Host", request.Host)
 	}
 
 	if destination.Get("X-Forwarded-Proto") == "" {
-		destination.Set("X-Forwarded-Proto", sourceContext.Scheme())
+		if request.TLS != nil {
+			destination.Set("X-Forwarded-Proto", "https")
+		} else {
+			destination.Set("X-Forwarded-Proto", "http")
+		}
 	}
 
 	if destination.Get("X-Original-URI") == "" {
-		destination.Set("X-Original-URI", sourceContext.Request().URL.RequestURI())
+		destination.Set("X-Original-URI", request.URL.RequestURI())
 	}
 }
 
+// Request forwards the request in context to url and streams the response back, https urls are verified against the system certificates
 func Request(context echo.Context, url string) (err error) {
+	return RequestWithTLSConfig(context, url, nil)
+}
+
+// RequestWithTLSConfig works like Request but connects to https urls with a custom TLS config e.g. from utils.GetClientCertificateTLSConfig for mutual TLS.
+// The default TLS profile (see utils.SetDefaultTLSProfile) is applied to a copy of the config.
+func RequestWithTLSConfig(context echo.Context, url string, tlsConfig *tls.Config) (err error) {
 	transport := &http.Transport{
 		Dial: (&net.Dialer{
 			Timeout: 5 * time.Second,
 		}).Dial,
 		TLSHandshakeTimeout: 5 * time.Second,
+		TLSClientConfig:     utils.ApplyDefaultTLSProfile(tlsConfig),
 	}
 
 	client := &http.Client{
@@ -0,0 +1,89 @@
+package proxy
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/mojlighetsministeriet/utils"
+	"github.com/mojlighetsministeriet/utils/tlstest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestWithTLSConfig(test *testing.T) {
+	authority, err := tlstest.NewCertificateAuthority()
+	assert.NoError(test, err)
+
+	serverCertificate, err := authority.IssueServerCertificate("127.0.0.1")
+	assert.NoError(test, err)
+
+	clientCertificate, err := authority.IssueClientCertificate("proxy")
+	assert.NoError(test, err)
+
+	backend := httptest.NewUnstartedServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "application/json")
+		writer.Write([]byte("{\"peer\":\"" + request.TLS.PeerCertificates[0].Subject.CommonName + "\",\"forwardedHost\":\"" + request.Header.Get("X-Forwarded-Host") + "\"}"))
+	}))
+	backend.TLS = serverCertificate.MutualServerTLSConfig()
+	backend.StartTLS()
+	defer backend.Close()
+
+	server := echo.New()
+	recorder := httptest.NewRecorder()
+	context := server.NewContext(httptest.NewRequest(echo.GET, "/", nil), recorder)
+
+	err = RequestWithTLSConfig(context, backend.URL, clientCertificate.ClientTLSConfig())
+	assert.NoError(test, err)
+	assert.Equal(test, http.StatusOK, recorder.Code)
+	assert.Equal(test, "{\"peer\":\"proxy\",\"forwardedHost\":\"example.com\"}", recorder.Body.String())
+}
+
+func TestFailRequestWithUntrustedServer(test *testing.T) {
+	backend := httptest.NewTLSServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusOK)
+	}))
+	defer backend.Close()
+
+	server := echo.New()
+	recorder := httptest.NewRecorder()
+	context := server.NewContext(httptest.NewRequest(echo.GET, "/", nil), recorder)
+
+	err := Request(context, backend.URL)
+	assert.NoError(test, err)
+	assert.Equal(test, http.StatusNotFound, recorder.Code)
+
+	body, err := ioutil.ReadAll(recorder.Body)
+	assert.NoError(test, err)
+	assert.Equal(test, "{\"message\":\"Not Found\"}", string(body))
+}
+
+func TestSetProxyRequestHeaders(test *testing.T) {
+	utils.SetTrustedProxies(append(utils.DefaultTrustedProxies, utils.PrivateNetworks...)...)
+	defer utils.SetTrustedProxies(utils.DefaultTrustedProxies...)
+
+	server := echo.New()
+
+	request := httptest.NewRequest(echo.GET, "/a/path?with=query", nil)
+	request.Header.Set("X-Forwarded-For", "203.0.113.99")
+	request.Header.Set("X-Forwarded-Host", "attacker.example.com")
+	context := server.NewContext(request, httptest.NewRecorder())
+
+	destination := http.Header{}
+	setProxyRequestHeaders(destination, context)
+	assert.Equal(test, "192.0.2.1", destination.Get("X-Forwarded-For"))
+	assert.Equal(test, "example.com", destination.Get("X-Forwarded-Host"))
+	assert.Equal(test, "http", destination.Get("X-Forwarded-Proto"))
+	assert.Equal(test, "/a/path?with=query", destination.Get("X-Original-URI"))
+
+	request.RemoteAddr = "10.0.0.1:1234"
+	request.Header.Set("X-Forwarded-Host", "internt.mojlighetsministeriet.se")
+	request.Header.Set("X-Forwarded-Proto", "https")
+
+	destination = http.Header{}
+	setProxyRequestHeaders(destination, context)
+	assert.Equal(test, "203.0.113.99, 10.0.0.1", destination.Get("X-Forwarded-For"))
+	assert.Equal(test, "internt.mojlighetsministeriet.se", destination.Get("X-Forwarded-Host"))
+	assert.Equal(test, "https", destination.Get("X-Forwarded-Proto"))
+}
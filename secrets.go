@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultSecretsDirectory is the directory where docker compose, docker swarm and kubernetes (by convention) mount secrets
+const DefaultSecretsDirectory = "/run/secrets"
+
+// LoadSecrets reads every file in the directory set in SECRETS_DIRECTORY, or DefaultSecretsDirectory, into a map with the file names as keys
+func LoadSecrets() (map[string]string, error) {
+	return LoadSecretsFromDirectory(GetEnv("SECRETS_DIRECTORY", DefaultSecretsDirectory))
+}
+
+// LoadSecretsFromDirectory reads every file in a directory into a map with the file names as keys. Sub directories and hidden files, such as the ..data links kubernetes creates, are skipped
+func LoadSecretsFromDirectory(directory string) (secrets map[string]string, err error) {
+	files, err := ioutil.ReadDir(directory)
+	if err != nil {
+		return
+	}
+
+	result := make(map[string]string)
+
+	for _, file := range files {
+		if strings.HasPrefix(file.Name(), ".") {
+			continue
+		}
+
+		path := filepath.Join(directory, file.Name())
+
+		// Stat follows symlinks, kubernetes mounts every secret as a link into the ..data directory
+		info, statErr := os.Stat(path)
+		if statErr != nil || info.IsDir() {
+			continue
+		}
+
+		var content []byte
+		content, err = ioutil.ReadFile(path)
+		if err != nil {
+			return
+		}
+
+		result[file.Name()] = strings.Trim(string(content), "\n ")
+		recordConfigValue(path, result[file.Name()], ConfigSourceFile.String(), true)
+	}
+
+	secrets = result
+
+	return
+}
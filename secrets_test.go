@@ -0,0 +1,52 @@
+package utils_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mojlighetsministeriet/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadSecretsFromDirectory(test *testing.T) {
+	directory, err := ioutil.TempDir("", "secrets")
+	assert.NoError(test, err)
+	defer os.RemoveAll(directory)
+
+	err = os.Mkdir(filepath.Join(directory, "..data"), 0700)
+	assert.NoError(test, err)
+	err = ioutil.WriteFile(filepath.Join(directory, "..data", "smtp-password"), []byte("a password\n"), 0600)
+	assert.NoError(test, err)
+	err = os.Symlink(filepath.Join(directory, "..data", "smtp-password"), filepath.Join(directory, "smtp-password"))
+	assert.NoError(test, err)
+	err = ioutil.WriteFile(filepath.Join(directory, "database-password"), []byte("another password"), 0600)
+	assert.NoError(test, err)
+
+	secrets, err := utils.LoadSecretsFromDirectory(directory)
+	assert.NoError(test, err)
+	assert.Equal(test, map[string]string{"smtp-password": "a password", "database-password": "another password"}, secrets)
+}
+
+func TestLoadSecretsFromEnvironmentDirectory(test *testing.T) {
+	directory, err := ioutil.TempDir("", "secrets")
+	assert.NoError(test, err)
+	defer os.RemoveAll(directory)
+
+	err = ioutil.WriteFile(filepath.Join(directory, "private-key"), []byte("a key"), 0600)
+	assert.NoError(test, err)
+
+	os.Setenv("SECRETS_DIRECTORY", directory)
+	defer os.Unsetenv("SECRETS_DIRECTORY")
+
+	secrets, err := utils.LoadSecrets()
+	assert.NoError(test, err)
+	assert.Equal(test, "a key", secrets["private-key"])
+}
+
+func TestFailLoadSecretsFromDirectoryWithMissingDirectory(test *testing.T) {
+	secrets, err := utils.LoadSecretsFromDirectory("/a-missing-secrets-directory")
+	assert.Error(test, err)
+	assert.Nil(test, secrets)
+}
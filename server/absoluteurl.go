@@ -0,0 +1,91 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/labstack/echo"
+	"github.com/mojlighetsministeriet/utils"
+)
+
+// AbsoluteURL returns the external URL to the route named routeName, the path parameters (:name and *) are replaced by params in order and escaped.
+// The URL starts with the original base URL of the request in context, including any forwarded prefix (see utils.GetOriginalSystemURLFromContext),
+// which makes it suitable for Location headers and links in emails. Query values are added if query is not empty.
+func (server *Server) AbsoluteURL(context echo.Context, routeName string, query url.Values, params ...interface{}) (absoluteURL string, err error) {
+	path, err := server.routePath(routeName, params)
+	if err != nil {
+		return
+	}
+
+	absoluteURL = utils.GetOriginalSystemURLFromContext(context) + path
+
+	if len(query) > 0 {
+		absoluteURL += "?" + query.Encode()
+	}
+
+	return
+}
+
+func (server *Server) routePath(routeName string, params []interface{}) (path string, err error) {
+	var route *echo.Route
+	for _, candidate := range server.Routes() {
+		if candidate.Name == routeName {
+			route = candidate
+			break
+		}
+	}
+
+	if route == nil {
+		err = errors.New("There is no route named " + routeName)
+		return
+	}
+
+	var builder strings.Builder
+	paramIndex := 0
+
+	for index := 0; index < len(route.Path); index++ {
+		character := route.Path[index]
+		if character != ':' && character != '*' {
+			builder.WriteByte(character)
+			continue
+		}
+
+		if paramIndex >= len(params) {
+			err = errors.New("Missing parameters for route " + routeName + " " + route.Path)
+			return
+		}
+
+		value := fmt.Sprint(params[paramIndex])
+		paramIndex++
+
+		if character == '*' {
+			builder.WriteString(escapePathSegments(value))
+			continue
+		}
+
+		builder.WriteString(url.PathEscape(value))
+		for index+1 < len(route.Path) && route.Path[index+1] != '/' {
+			index++
+		}
+	}
+
+	if paramIndex < len(params) {
+		err = errors.New("Too many parameters for route " + routeName + " " + route.Path)
+		return
+	}
+
+	path = builder.String()
+
+	return
+}
+
+func escapePathSegments(value string) string {
+	segments := strings.Split(value, "/")
+	for index, segment := range segments {
+		segments[index] = url.PathEscape(segment)
+	}
+
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/mojlighetsministeriet/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func newAbsoluteURLServer() *Server {
+	server := NewServer(false, true, "1M")
+	handler := func(context echo.Context) error {
+		return context.NoContent(http.StatusOK)
+	}
+
+	server.GET("/accounts/:id/reset-password/:token", handler).Name = "resetPassword"
+	server.GET("/files/*", handler).Name = "files"
+	server.GET("/", handler).Name = "root"
+
+	return server
+}
+
+func TestAbsoluteURL(test *testing.T) {
+	utils.SetTrustedProxies(append(utils.DefaultTrustedProxies, utils.PrivateNetworks...)...)
+	defer utils.SetTrustedProxies(utils.DefaultTrustedProxies...)
+
+	server := newAbsoluteURLServer()
+
+	request := httptest.NewRequest(echo.GET, "/", nil)
+	request.RemoteAddr = "10.0.0.1:1234"
+	request.Header.Set("X-Forwarded-Proto", "https")
+	request.Header.Set("X-Forwarded-Host", "internt.mojlighetsministeriet.se")
+	request.Header.Set("X-Forwarded-Prefix", "/account")
+	context := server.NewContext(request, httptest.NewRecorder())
+
+	absoluteURL, err := server.AbsoluteURL(context, "resetPassword", url.Values{"redirect": {"/home?tab=1"}}, 42, "a token/with spaces")
+	assert.NoError(test, err)
+	assert.Equal(test, "https://internt.mojlighetsministeriet.se/account/accounts/42/reset-password/a%20token%2Fwith%20spaces?redirect=%2Fhome%3Ftab%3D1", absoluteURL)
+
+	absoluteURL, err = server.AbsoluteURL(context, "files", nil, "documents/årsredovisning 2018.pdf")
+	assert.NoError(test, err)
+	assert.Equal(test, "https://internt.mojlighetsministeriet.se/account/files/documents/%C3%A5rsredovisning%202018.pdf", absoluteURL)
+
+	absoluteURL, err = server.AbsoluteURL(context, "root", nil)
+	assert.NoError(test, err)
+	assert.Equal(test, "https://internt.mojlighetsministeriet.se/account/", absoluteURL)
+}
+
+func TestFailAbsoluteURL(test *testing.T) {
+	server := newAbsoluteURLServer()
+	context := server.NewContext(httptest.NewRequest(echo.GET, "/", nil), httptest.NewRecorder())
+
+	_, err := server.AbsoluteURL(context, "missing", nil)
+	assert.Error(test, err)
+	assert.Equal(test, "There is no route named missing", err.Error())
+
+	_, err = server.AbsoluteURL(context, "resetPassword", nil, 42)
+	assert.Error(test, err)
+	assert.Equal(test, "Missing parameters for route resetPassword /accounts/:id/reset-password/:token", err.Error())
+
+	_, err = server.AbsoluteURL(context, "root", nil, 42)
+	assert.Error(test, err)
+	assert.Equal(test, "Too many parameters for route root /", err.Error())
+}
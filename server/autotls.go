@@ -0,0 +1,71 @@
+package server
+
+import (
+	"errors"
+	"time"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// DefaultAutoTLSCacheDirectory is where certificates from Let's Encrypt are stored unless another cache is configured
+const DefaultAutoTLSCacheDirectory = "/var/www/.cache"
+
+// LetsEncryptStagingURL is the ACME directory of the Let's Encrypt staging environment, it has higher rate limits but issues untrusted certificates
+const LetsEncryptStagingURL = "https://acme-staging-v02.api.letsencrypt.org/directory"
+
+// AutoTLSConfig describes how certificates are requested from Let's Encrypt or another ACME server
+type AutoTLSConfig struct {
+	// Cache stores certificates and the account key, it defaults to an autocert.DirCache in CacheDirectory
+	Cache autocert.Cache
+	// CacheDirectory is used when Cache is not set, it defaults to DefaultAutoTLSCacheDirectory
+	CacheDirectory string
+	// Hosts are the only host names certificates will be requested for, if empty any SNI name triggers a request
+	Hosts []string
+	// Email is the contact address used by the ACME server to notify about problems with the certificates
+	Email string
+	// DirectoryURL is the ACME directory, it defaults to the Let's Encrypt production environment
+	DirectoryURL string
+	// RenewBefore is how long before expiry certificates are renewed, it defaults to 30 days
+	RenewBefore time.Duration
+	// DisableLogging stops issued and renewed certificates from being logged
+	DisableLogging bool
+}
+
+// ConfigureAutoTLS replaces the default configuration used to request certificates from Let's Encrypt
+func (server *Server) ConfigureAutoTLS(config AutoTLSConfig) (err error) {
+	if !server.useTLS {
+		err = errors.New("The server must be created with TLS enabled to configure automatic certificates")
+		return
+	}
+
+	cache := config.Cache
+	if cache == nil {
+		cacheDirectory := config.CacheDirectory
+		if cacheDirectory == "" {
+			cacheDirectory = DefaultAutoTLSCacheDirectory
+		}
+
+		cache = autocert.DirCache(cacheDirectory)
+	}
+
+	if !config.DisableLogging {
+		cache = NewLoggingCache(cache, server.Logger)
+	}
+
+	server.AutoTLSManager.Cache = cache
+	server.AutoTLSManager.Email = config.Email
+	server.AutoTLSManager.RenewBefore = config.RenewBefore
+	server.AutoTLSManager.HostPolicy = nil
+	server.AutoTLSManager.Client = nil
+
+	if len(config.Hosts) > 0 {
+		server.AutoTLSManager.HostPolicy = autocert.HostWhitelist(config.Hosts...)
+	}
+
+	if config.DirectoryURL != "" {
+		server.AutoTLSManager.Client = &acme.Client{DirectoryURL: config.DirectoryURL}
+	}
+
+	return
+}
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+func TestConfigureAutoTLS(test *testing.T) {
+	server := NewServer(true, false, "1M")
+	assert.Equal(test, autocert.DirCache(DefaultAutoTLSCacheDirectory), server.AutoTLSManager.Cache)
+
+	cache := NewMemoryCache()
+	err := server.ConfigureAutoTLS(AutoTLSConfig{
+		Cache:        cache,
+		Hosts:        []string{"mojlighetsministeriet.se"},
+		Email:        "admin@mojlighetsministeriet.se",
+		DirectoryURL: LetsEncryptStagingURL,
+		RenewBefore:  14 * 24 * time.Hour,
+	})
+	assert.NoError(test, err)
+
+	assert.Equal(test, NewLoggingCache(cache, server.Logger), server.AutoTLSManager.Cache)
+	assert.Equal(test, "admin@mojlighetsministeriet.se", server.AutoTLSManager.Email)
+	assert.Equal(test, LetsEncryptStagingURL, server.AutoTLSManager.Client.DirectoryURL)
+	assert.Equal(test, 14*24*time.Hour, server.AutoTLSManager.RenewBefore)
+	assert.NoError(test, server.AutoTLSManager.HostPolicy(context.Background(), "mojlighetsministeriet.se"))
+	assert.Error(test, server.AutoTLSManager.HostPolicy(context.Background(), "evil.example.com"))
+}
+
+func TestConfigureAutoTLSWithCacheDirectory(test *testing.T) {
+	server := NewServer(true, false, "1M")
+
+	err := server.ConfigureAutoTLS(AutoTLSConfig{CacheDirectory: "/tmp/autotls-cache", DisableLogging: true})
+	assert.NoError(test, err)
+	assert.Equal(test, autocert.DirCache("/tmp/autotls-cache"), server.AutoTLSManager.Cache)
+	assert.Nil(test, server.AutoTLSManager.HostPolicy)
+	assert.Nil(test, server.AutoTLSManager.Client)
+}
+
+func TestFailConfigureAutoTLSWithoutTLS(test *testing.T) {
+	server := NewServer(false, false, "1M")
+
+	err := server.ConfigureAutoTLS(AutoTLSConfig{})
+	assert.Error(test, err)
+	assert.Equal(test, "The server must be created with TLS enabled to configure automatic certificates", err.Error())
+}
@@ -0,0 +1,206 @@
+package server
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/labstack/echo"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// MemoryCache is an autocert.Cache that keeps everything in memory, it is intended for tests since certificates are lost on restart
+type MemoryCache struct {
+	mutex   sync.RWMutex
+	entries map[string][]byte
+}
+
+// NewMemoryCache creates an empty MemoryCache
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: make(map[string][]byte)}
+}
+
+// Get returns the data stored for key or autocert.ErrCacheMiss
+func (cache *MemoryCache) Get(ctx context.Context, key string) (data []byte, err error) {
+	cache.mutex.RLock()
+	defer cache.mutex.RUnlock()
+
+	data, found := cache.entries[key]
+	if !found {
+		err = autocert.ErrCacheMiss
+		return
+	}
+
+	data = append([]byte(nil), data...)
+
+	return
+}
+
+// Put stores data for key
+func (cache *MemoryCache) Put(ctx context.Context, key string, data []byte) error {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	cache.entries[key] = append([]byte(nil), data...)
+
+	return nil
+}
+
+// Delete removes the data stored for key
+func (cache *MemoryCache) Delete(ctx context.Context, key string) error {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	delete(cache.entries, key)
+
+	return nil
+}
+
+// EncryptedDirCache is an autocert.Cache that stores certificates and the account key in a directory encrypted with AES-GCM,
+// so that the private keys are not readable by someone that only has access to the directory, e.g. a shared volume or a backup
+type EncryptedDirCache struct {
+	cache autocert.DirCache
+	aead  cipher.AEAD
+}
+
+// NewEncryptedDirCache creates an EncryptedDirCache in directory, key must be 32 bytes (AES-256)
+func NewEncryptedDirCache(directory string, key []byte) (cache *EncryptedDirCache, err error) {
+	if len(key) != 32 {
+		err = errors.New("The encryption key must be 32 bytes")
+		return
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return
+	}
+
+	cache = &EncryptedDirCache{cache: autocert.DirCache(directory), aead: aead}
+
+	return
+}
+
+// Get decrypts the data stored for key or returns autocert.ErrCacheMiss
+func (cache *EncryptedDirCache) Get(ctx context.Context, key string) (data []byte, err error) {
+	encrypted, err := cache.cache.Get(ctx, cache.filename(key))
+	if err != nil {
+		return
+	}
+
+	nonceSize := cache.aead.NonceSize()
+	if len(encrypted) < nonceSize {
+		err = errors.New("The cached data for " + key + " is too short to be decrypted")
+		return
+	}
+
+	data, err = cache.aead.Open(nil, encrypted[:nonceSize], encrypted[nonceSize:], []byte(key))
+	if err != nil {
+		err = errors.New("The cached data for " + key + " could not be decrypted")
+	}
+
+	return
+}
+
+// Put encrypts and stores data for key
+func (cache *EncryptedDirCache) Put(ctx context.Context, key string, data []byte) (err error) {
+	nonce := make([]byte, cache.aead.NonceSize())
+	_, err = io.ReadFull(rand.Reader, nonce)
+	if err != nil {
+		return
+	}
+
+	err = cache.cache.Put(ctx, cache.filename(key), cache.aead.Seal(nonce, nonce, data, []byte(key)))
+
+	return
+}
+
+// Delete removes the data stored for key
+func (cache *EncryptedDirCache) Delete(ctx context.Context, key string) error {
+	return cache.cache.Delete(ctx, cache.filename(key))
+}
+
+// filename hides which host names there are certificates for
+func (cache *EncryptedDirCache) filename(key string) string {
+	checksum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(checksum[:])
+}
+
+// LoggingCache wraps an autocert.Cache and logs when certificates are issued, renewed or deleted
+type LoggingCache struct {
+	cache  autocert.Cache
+	logger echo.Logger
+}
+
+// NewLoggingCache creates a LoggingCache that logs to logger, e.g. the Logger of a Server
+func NewLoggingCache(cache autocert.Cache, logger echo.Logger) *LoggingCache {
+	return &LoggingCache{cache: cache, logger: logger}
+}
+
+// Get returns the data stored for key in the wrapped cache
+func (cache *LoggingCache) Get(ctx context.Context, key string) ([]byte, error) {
+	return cache.cache.Get(ctx, key)
+}
+
+// Put stores data for key in the wrapped cache and logs if it is a certificate
+func (cache *LoggingCache) Put(ctx context.Context, key string, data []byte) (err error) {
+	host, isCertificate := certificateHostFromCacheKey(key)
+	_, getErr := cache.cache.Get(ctx, key)
+	isRenewal := getErr == nil
+
+	err = cache.cache.Put(ctx, key, data)
+	if err != nil {
+		cache.logger.Error("Failed to store certificate data for " + key + ": " + err.Error())
+		return
+	}
+
+	if isCertificate {
+		if isRenewal {
+			cache.logger.Info("Renewed certificate for " + host)
+		} else {
+			cache.logger.Info("Issued certificate for " + host)
+		}
+	}
+
+	return
+}
+
+// Delete removes the data stored for key in the wrapped cache and logs if it is a certificate
+func (cache *LoggingCache) Delete(ctx context.Context, key string) (err error) {
+	err = cache.cache.Delete(ctx, key)
+	if err == nil {
+		if host, isCertificate := certificateHostFromCacheKey(key); isCertificate {
+			cache.logger.Info("Deleted certificate for " + host)
+		}
+	}
+
+	return
+}
+
+// certificateHostFromCacheKey tells if an autocert cache key is a certificate, the other keys are the account key (acme_account+key),
+// challenge tokens (host+token and token+http-01) and RSA certificates that are stored as host+rsa
+func certificateHostFromCacheKey(key string) (host string, isCertificate bool) {
+	if strings.HasSuffix(key, "+rsa") {
+		host = strings.TrimSuffix(key, "+rsa")
+		isCertificate = true
+		return
+	}
+
+	if !strings.Contains(key, "+") {
+		host = key
+		isCertificate = true
+	}
+
+	return
+}
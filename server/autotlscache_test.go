@@ -0,0 +1,99 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/labstack/gommon/log"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+func TestMemoryCache(test *testing.T) {
+	cache := NewMemoryCache()
+
+	_, err := cache.Get(context.Background(), "mojlighetsministeriet.se")
+	assert.Equal(test, autocert.ErrCacheMiss, err)
+
+	assert.NoError(test, cache.Put(context.Background(), "mojlighetsministeriet.se", []byte("certificate")))
+	data, err := cache.Get(context.Background(), "mojlighetsministeriet.se")
+	assert.NoError(test, err)
+	assert.Equal(test, "certificate", string(data))
+
+	assert.NoError(test, cache.Delete(context.Background(), "mojlighetsministeriet.se"))
+	_, err = cache.Get(context.Background(), "mojlighetsministeriet.se")
+	assert.Equal(test, autocert.ErrCacheMiss, err)
+}
+
+func TestEncryptedDirCache(test *testing.T) {
+	directory, err := ioutil.TempDir("", "encrypteddircache")
+	assert.NoError(test, err)
+	defer os.RemoveAll(directory)
+
+	key := []byte("0123456789abcdef0123456789abcdef")
+	cache, err := NewEncryptedDirCache(directory, key)
+	assert.NoError(test, err)
+
+	assert.NoError(test, cache.Put(context.Background(), "mojlighetsministeriet.se", []byte("a private key")))
+
+	files, err := ioutil.ReadDir(directory)
+	assert.NoError(test, err)
+	assert.Equal(test, 1, len(files))
+	assert.Equal(test, false, strings.Contains(files[0].Name(), "mojlighetsministeriet"))
+
+	content, err := ioutil.ReadFile(filepath.Join(directory, files[0].Name()))
+	assert.NoError(test, err)
+	assert.Equal(test, false, bytes.Contains(content, []byte("a private key")))
+
+	data, err := cache.Get(context.Background(), "mojlighetsministeriet.se")
+	assert.NoError(test, err)
+	assert.Equal(test, "a private key", string(data))
+
+	otherCache, err := NewEncryptedDirCache(directory, []byte("fedcba9876543210fedcba9876543210"))
+	assert.NoError(test, err)
+	_, err = otherCache.Get(context.Background(), "mojlighetsministeriet.se")
+	assert.Error(test, err)
+	assert.Equal(test, "The cached data for mojlighetsministeriet.se could not be decrypted", err.Error())
+
+	assert.NoError(test, cache.Delete(context.Background(), "mojlighetsministeriet.se"))
+	_, err = cache.Get(context.Background(), "mojlighetsministeriet.se")
+	assert.Equal(test, autocert.ErrCacheMiss, err)
+}
+
+func TestFailNewEncryptedDirCacheWithShortKey(test *testing.T) {
+	_, err := NewEncryptedDirCache("/tmp/encrypteddircache", []byte("too short"))
+	assert.Error(test, err)
+	assert.Equal(test, "The encryption key must be 32 bytes", err.Error())
+}
+
+func TestLoggingCache(test *testing.T) {
+	output := new(bytes.Buffer)
+	logger := log.New("test")
+	logger.SetOutput(output)
+	logger.SetLevel(log.INFO)
+
+	cache := NewLoggingCache(NewMemoryCache(), logger)
+
+	assert.NoError(test, cache.Put(context.Background(), "acme_account+key", []byte("account key")))
+	assert.NoError(test, cache.Put(context.Background(), "mojlighetsministeriet.se+token", []byte("token")))
+	assert.Equal(test, "", output.String())
+
+	assert.NoError(test, cache.Put(context.Background(), "mojlighetsministeriet.se", []byte("certificate")))
+	assert.Equal(test, true, strings.Contains(output.String(), "Issued certificate for mojlighetsministeriet.se"))
+
+	assert.NoError(test, cache.Put(context.Background(), "mojlighetsministeriet.se+rsa", []byte("certificate")))
+	assert.NoError(test, cache.Put(context.Background(), "mojlighetsministeriet.se+rsa", []byte("renewed certificate")))
+	assert.Equal(test, true, strings.Contains(output.String(), "Renewed certificate for mojlighetsministeriet.se"))
+
+	data, err := cache.Get(context.Background(), "mojlighetsministeriet.se+rsa")
+	assert.NoError(test, err)
+	assert.Equal(test, "renewed certificate", string(data))
+
+	assert.NoError(test, cache.Delete(context.Background(), "mojlighetsministeriet.se"))
+	assert.Equal(test, true, strings.Contains(output.String(), "Deleted certificate for mojlighetsministeriet.se"))
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/mojlighetsministeriet/utils"
+)
+
+// CertificateHealth is the response of the certificate health resource
+type CertificateHealth struct {
+	Healthy      bool                    `json:"healthy"`
+	Threshold    string                  `json:"threshold"`
+	Certificates []utils.CertificateInfo `json:"certificates"`
+	Message      string                  `json:"message,omitempty"`
+}
+
+// AddCertificateHealthResource registers GET /health/certificates that lists the certificates in paths (see utils.InspectCertificates) with
+// the days left before they expire. It responds with 503 Service Unavailable if a certificate expires within threshold or can not be read.
+// The response reveals certificate subjects, so protect it with middlewares unless the certificates are public anyway.
+func (server *Server) AddCertificateHealthResource(threshold time.Duration, paths []string, middlewares ...echo.MiddlewareFunc) {
+	server.GET("/health/certificates", func(context echo.Context) error {
+		health := CertificateHealth{Healthy: true, Threshold: threshold.String()}
+
+		certificates, err := utils.InspectCertificates(paths...)
+		if err != nil {
+			health.Healthy = false
+			health.Message = err.Error()
+			return context.JSON(http.StatusServiceUnavailable, health)
+		}
+
+		health.Certificates = certificates
+
+		if len(utils.FilterExpiringCertificates(certificates, threshold)) > 0 {
+			health.Healthy = false
+			return context.JSON(http.StatusServiceUnavailable, health)
+		}
+
+		return context.JSON(http.StatusOK, health)
+	}, middlewares...)
+}
+
+// WarnAboutExpiringCertificates logs a warning for every certificate in paths that expires within threshold, the certificates are checked now and then once every interval
+// until stop is called, stop waits for a check that is running to finish
+func (server *Server) WarnAboutExpiringCertificates(threshold time.Duration, interval time.Duration, paths ...string) (stop func(), err error) {
+	if interval <= 0 {
+		err = errors.New("The interval to check certificates must be positive")
+		return
+	}
+
+	server.warnAboutExpiringCertificates(threshold, paths)
+
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	var stopOnce sync.Once
+
+	go func() {
+		defer close(finished)
+
+		for {
+			select {
+			case <-ticker.C:
+				server.warnAboutExpiringCertificates(threshold, paths)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	stop = func() {
+		stopOnce.Do(func() {
+			ticker.Stop()
+			close(done)
+		})
+
+		<-finished
+	}
+
+	return
+}
+
+func (server *Server) warnAboutExpiringCertificates(threshold time.Duration, paths []string) {
+	certificates, err := utils.InspectCertificates(paths...)
+	if err != nil {
+		server.Logger.Warn("Unable to inspect certificates: " + err.Error())
+		return
+	}
+
+	for _, certificate := range utils.FilterExpiringCertificates(certificates, threshold) {
+		server.Logger.Warn("Certificate " + certificate.Subject + " in " + certificate.Source + " expires in " + strconv.Itoa(certificate.DaysLeft) + " days (" + certificate.NotAfter.Format(time.RFC3339) + ")")
+	}
+}
@@ -0,0 +1,141 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/labstack/gommon/log"
+	"github.com/mojlighetsministeriet/utils/tlstest"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeCertificate(test *testing.T, path string, validFor time.Duration) {
+	authority, err := tlstest.NewCertificateAuthority()
+	assert.NoError(test, err)
+	authority.Validity = validFor
+
+	certificate, err := authority.IssueServerCertificate("internal.example.com")
+	assert.NoError(test, err)
+	assert.NoError(test, ioutil.WriteFile(path, certificate.CertificatePEM, 0600))
+}
+
+func TestAddCertificateHealthResource(test *testing.T) {
+	writeCertificate(test, "/tmp/a-healthy-certificate.pem", 90*24*time.Hour)
+	defer os.Remove("/tmp/a-healthy-certificate.pem")
+
+	server := NewServer(false, false, "1M")
+	server.AddCertificateHealthResource(14*24*time.Hour, []string{"/tmp/a-healthy-certificate.pem"})
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(echo.GET, "/health/certificates", nil))
+	assert.Equal(test, http.StatusOK, recorder.Code)
+
+	health := CertificateHealth{}
+	assert.NoError(test, json.Unmarshal(recorder.Body.Bytes(), &health))
+	assert.Equal(test, true, health.Healthy)
+	assert.Equal(test, "336h0m0s", health.Threshold)
+	assert.Equal(test, "CN=internal.example.com", health.Certificates[0].Subject)
+	assert.Equal(test, 89, health.Certificates[0].DaysLeft)
+}
+
+func TestAddCertificateHealthResourceWithExpiringCertificate(test *testing.T) {
+	writeCertificate(test, "/tmp/an-expiring-certificate.pem", 2*24*time.Hour)
+	defer os.Remove("/tmp/an-expiring-certificate.pem")
+
+	server := NewServer(false, false, "1M")
+	server.AddCertificateHealthResource(14*24*time.Hour, []string{"/tmp/an-expiring-certificate.pem"})
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(echo.GET, "/health/certificates", nil))
+	assert.Equal(test, http.StatusServiceUnavailable, recorder.Code)
+
+	output := new(bytes.Buffer)
+	server.Logger.SetOutput(output)
+	server.Logger.SetLevel(log.WARN)
+	server.warnAboutExpiringCertificates(14*24*time.Hour, []string{"/tmp/an-expiring-certificate.pem"})
+	assert.Equal(test, true, strings.Contains(output.String(), "Certificate CN=internal.example.com in /tmp/an-expiring-certificate.pem expires in 1 days"))
+}
+
+func TestAddCertificateHealthResourceWithMissingCertificate(test *testing.T) {
+	server := NewServer(false, false, "1M")
+	server.AddCertificateHealthResource(time.Hour, []string{"/tmp/a-missing-certificate.pem"})
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(echo.GET, "/health/certificates", nil))
+	assert.Equal(test, http.StatusServiceUnavailable, recorder.Code)
+}
+
+func TestAddCertificateHealthResourceWithMiddleware(test *testing.T) {
+	writeCertificate(test, "/tmp/a-protected-certificate.pem", 90*24*time.Hour)
+	defer os.Remove("/tmp/a-protected-certificate.pem")
+
+	server := NewServer(false, false, "1M")
+	server.AddCertificateHealthResource(14*24*time.Hour, []string{"/tmp/a-protected-certificate.pem"}, func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(context echo.Context) error {
+			return context.NoContent(http.StatusUnauthorized)
+		}
+	})
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(echo.GET, "/health/certificates", nil))
+	assert.Equal(test, http.StatusUnauthorized, recorder.Code)
+}
+
+func TestWarnAboutExpiringCertificates(test *testing.T) {
+	writeCertificate(test, "/tmp/a-warned-certificate.pem", 2*24*time.Hour)
+	defer os.Remove("/tmp/a-warned-certificate.pem")
+
+	server := NewServer(false, false, "1M")
+	output := new(safeBuffer)
+	server.Logger.SetOutput(output)
+	server.Logger.SetLevel(log.WARN)
+
+	stop, err := server.WarnAboutExpiringCertificates(14*24*time.Hour, 10*time.Millisecond, "/tmp/a-warned-certificate.pem")
+	assert.NoError(test, err)
+
+	time.Sleep(50 * time.Millisecond)
+	stop()
+	stop()
+
+	warnings := strings.Count(output.String(), "expires in 1 days")
+	assert.Equal(test, true, warnings > 1)
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(test, warnings, strings.Count(output.String(), "expires in 1 days"))
+}
+
+func TestFailWarnAboutExpiringCertificatesWithoutInterval(test *testing.T) {
+	server := NewServer(false, false, "1M")
+
+	stop, err := server.WarnAboutExpiringCertificates(time.Hour, 0, "/tmp/a-certificate.pem")
+	assert.Error(test, err)
+	assert.Equal(test, "The interval to check certificates must be positive", err.Error())
+	assert.Nil(test, stop)
+}
+
+// safeBuffer is a bytes.Buffer that can be written by the warning goroutine while the test reads it
+type safeBuffer struct {
+	mutex  sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (buffer *safeBuffer) Write(data []byte) (int, error) {
+	buffer.mutex.Lock()
+	defer buffer.mutex.Unlock()
+	return buffer.buffer.Write(data)
+}
+
+func (buffer *safeBuffer) String() string {
+	buffer.mutex.Lock()
+	defer buffer.mutex.Unlock()
+	return buffer.buffer.String()
+}
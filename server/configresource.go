@@ -0,0 +1,16 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo"
+	"github.com/mojlighetsministeriet/utils"
+)
+
+// AddConfigResource registers GET /config that lists every configuration value read through the utils config helpers with secrets masked.
+// The route is opt-in and should be protected e.g. server.AddConfigResource(jwt.RequiredRoleMiddleware(publicKey, "administrator"))
+func (server *Server) AddConfigResource(middlewares ...echo.MiddlewareFunc) {
+	server.GET("/config", func(context echo.Context) error {
+		return context.JSON(http.StatusOK, utils.GetConfigRecords())
+	}, middlewares...)
+}
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/mojlighetsministeriet/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddConfigResource(test *testing.T) {
+	os.Setenv("SERVER_CONFIG_RESOURCE_PASSWORD", "a password")
+	defer os.Unsetenv("SERVER_CONFIG_RESOURCE_PASSWORD")
+	utils.GetEnv("SERVER_CONFIG_RESOURCE_PASSWORD", "")
+
+	server := NewServer(false, false, "1M")
+	server.AddConfigResource()
+
+	request := httptest.NewRequest(echo.GET, "/config", nil)
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+
+	assert.Equal(test, http.StatusOK, recorder.Code)
+	assert.Equal(test, true, strings.Contains(recorder.Body.String(), `{"key":"SERVER_CONFIG_RESOURCE_PASSWORD","value":"********","source":"environment","secret":true}`))
+	assert.Equal(test, false, strings.Contains(recorder.Body.String(), "a password"))
+}
+
+func TestAddConfigResourceWithMiddleware(test *testing.T) {
+	server := NewServer(false, false, "1M")
+	server.AddConfigResource(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(context echo.Context) error {
+			return context.JSONBlob(http.StatusForbidden, []byte("{\"message\":\"Forbidden\"}"))
+		}
+	})
+
+	request := httptest.NewRequest(echo.GET, "/config", nil)
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+
+	assert.Equal(test, http.StatusForbidden, recorder.Code)
+}
@@ -0,0 +1,11 @@
+package server
+
+import (
+	"github.com/mojlighetsministeriet/utils/jwt"
+)
+
+// AddJWKSResource registers GET /.well-known/jwks.json that publishes the public keys from provider as a JSON Web Key Set,
+// so that other services, also those not written in Go, can verify the tokens issued by this service
+func (server *Server) AddJWKSResource(provider jwt.VerificationKeyProvider) {
+	server.GET(jwt.JWKSPath, jwt.JWKSHandler(provider))
+}
@@ -0,0 +1,34 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/mojlighetsministeriet/utils/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddJWKSResource(test *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(test, err)
+
+	server := NewServer(false, false, "1M")
+	server.AddJWKSResource(jwt.VerificationKeys{{ID: "2018-01", Key: privateKey.Public()}})
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(echo.GET, "/.well-known/jwks.json", nil))
+	assert.Equal(test, http.StatusOK, recorder.Code)
+	assert.Equal(test, "public, max-age=300", recorder.Header().Get("Cache-Control"))
+
+	keySet := jwt.JSONWebKeySet{}
+	assert.NoError(test, json.Unmarshal(recorder.Body.Bytes(), &keySet))
+	assert.Equal(test, 1, len(keySet.Keys))
+	assert.Equal(test, "2018-01", keySet.Keys[0].KeyID)
+	assert.Equal(test, "ES256", keySet.Keys[0].Algorithm)
+}
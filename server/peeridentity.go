@@ -0,0 +1,116 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo"
+)
+
+const peerIdentityContextKey = "peerIdentity"
+
+// PeerIdentity describes the verified client certificate of a mutual TLS connection
+type PeerIdentity struct {
+	Subject        string   `json:"subject"`
+	CommonName     string   `json:"commonName"`
+	DNSNames       []string `json:"dnsNames,omitempty"`
+	EmailAddresses []string `json:"emailAddresses,omitempty"`
+	IPAddresses    []string `json:"ipAddresses,omitempty"`
+	URIs           []string `json:"uris,omitempty"`
+	SPIFFEID       string   `json:"spiffeId,omitempty"`
+}
+
+// Matches tells if identity is the SPIFFE ID, one of the URIs or DNS names or the common name of the peer
+func (peer *PeerIdentity) Matches(identity string) bool {
+	if identity == "" {
+		return false
+	}
+
+	if peer.SPIFFEID == identity || peer.CommonName == identity {
+		return true
+	}
+
+	for _, uri := range peer.URIs {
+		if uri == identity {
+			return true
+		}
+	}
+
+	for _, name := range peer.DNSNames {
+		if name == identity {
+			return true
+		}
+	}
+
+	return false
+}
+
+// PeerIdentityMiddleware stores the identity of a verified client certificate on the echo.Context, see GetPeerIdentityFromContext
+func PeerIdentityMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(context echo.Context) error {
+			peer := newPeerIdentityFromRequest(context.Request())
+			if peer != nil {
+				context.Set(peerIdentityContextKey, peer)
+			}
+
+			return next(context)
+		}
+	}
+}
+
+// GetPeerIdentityFromContext returns the identity of the verified client certificate or nil if the request was made without one
+func GetPeerIdentityFromContext(context echo.Context) *PeerIdentity {
+	if peer, ok := context.Get(peerIdentityContextKey).(*PeerIdentity); ok {
+		return peer
+	}
+
+	return newPeerIdentityFromRequest(context.Request())
+}
+
+// RequiredPeerMiddleware is a echo middleware that will only allow requests from peers with a verified client certificate matching one of the identities, see PeerIdentity.Matches
+func RequiredPeerMiddleware(identities ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(context echo.Context) error {
+			peer := GetPeerIdentityFromContext(context)
+			if peer == nil {
+				return context.JSONBlob(http.StatusUnauthorized, []byte("{\"message\":\"Unauthorized\"}"))
+			}
+
+			for _, identity := range identities {
+				if peer.Matches(identity) {
+					return next(context)
+				}
+			}
+
+			return context.JSONBlob(http.StatusForbidden, []byte("{\"message\":\"Forbidden\"}"))
+		}
+	}
+}
+
+func newPeerIdentityFromRequest(request *http.Request) *PeerIdentity {
+	if request.TLS == nil || len(request.TLS.VerifiedChains) == 0 || len(request.TLS.VerifiedChains[0]) == 0 {
+		return nil
+	}
+
+	certificate := request.TLS.VerifiedChains[0][0]
+
+	peer := &PeerIdentity{
+		Subject:        certificate.Subject.String(),
+		CommonName:     certificate.Subject.CommonName,
+		DNSNames:       certificate.DNSNames,
+		EmailAddresses: certificate.EmailAddresses,
+	}
+
+	for _, ip := range certificate.IPAddresses {
+		peer.IPAddresses = append(peer.IPAddresses, ip.String())
+	}
+
+	for _, uri := range certificate.URIs {
+		peer.URIs = append(peer.URIs, uri.String())
+		if uri.Scheme == "spiffe" && peer.SPIFFEID == "" {
+			peer.SPIFFEID = uri.String()
+		}
+	}
+
+	return peer
+}
@@ -0,0 +1,206 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/mojlighetsministeriet/utils/tlstest"
+	"github.com/stretchr/testify/assert"
+)
+
+func newPeerRequest() *http.Request {
+	spiffeID, _ := url.Parse("spiffe://mojlighetsministeriet.se/service/billing")
+	certificate := &x509.Certificate{
+		Subject:  pkix.Name{CommonName: "billing", Organization: []string{"Möjlighetsministeriet"}},
+		DNSNames: []string{"billing.internal"},
+		URIs:     []*url.URL{spiffeID},
+	}
+
+	request := httptest.NewRequest(echo.GET, "/", nil)
+	request.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{certificate}}}
+
+	return request
+}
+
+func TestPeerIdentityMiddleware(test *testing.T) {
+	server := echo.New()
+	recorder := httptest.NewRecorder()
+	context := server.NewContext(newPeerRequest(), recorder)
+
+	var peer *PeerIdentity
+	handler := PeerIdentityMiddleware()(func(context echo.Context) error {
+		peer = GetPeerIdentityFromContext(context)
+		return context.NoContent(http.StatusOK)
+	})
+	handler(context)
+
+	assert.Equal(test, &PeerIdentity{
+		Subject:    "CN=billing,O=Möjlighetsministeriet",
+		CommonName: "billing",
+		DNSNames:   []string{"billing.internal"},
+		URIs:       []string{"spiffe://mojlighetsministeriet.se/service/billing"},
+		SPIFFEID:   "spiffe://mojlighetsministeriet.se/service/billing",
+	}, peer)
+}
+
+func TestRequiredPeerMiddleware(test *testing.T) {
+	server := echo.New()
+	okHandler := func(context echo.Context) error {
+		return context.NoContent(http.StatusOK)
+	}
+
+	recorder := httptest.NewRecorder()
+	RequiredPeerMiddleware("spiffe://mojlighetsministeriet.se/service/billing")(okHandler)(server.NewContext(newPeerRequest(), recorder))
+	assert.Equal(test, http.StatusOK, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	RequiredPeerMiddleware("billing.internal")(okHandler)(server.NewContext(newPeerRequest(), recorder))
+	assert.Equal(test, http.StatusOK, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	RequiredPeerMiddleware("accounts")(okHandler)(server.NewContext(newPeerRequest(), recorder))
+	assert.Equal(test, http.StatusForbidden, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	RequiredPeerMiddleware("billing")(okHandler)(server.NewContext(httptest.NewRequest(echo.GET, "/", nil), recorder))
+	assert.Equal(test, http.StatusUnauthorized, recorder.Code)
+}
+
+func TestRequireClientCertificates(test *testing.T) {
+	server := NewServer(true, false, "1M")
+
+	err := server.RequireClientCertificates("/etc/ssl/certs/ca-certificates.crt")
+	assert.NoError(test, err)
+
+	defer server.clientCAs.Close()
+
+	config := server.tlsConfig()
+	assert.Equal(test, tls.RequireAndVerifyClientCert, config.ClientAuth)
+
+	handshakeConfig, err := config.GetConfigForClient(&tls.ClientHelloInfo{})
+	assert.NoError(test, err)
+	assert.Equal(test, true, len(handshakeConfig.ClientCAs.Subjects()) > 10)
+
+	err = server.RequireClientCertificates("/a-missing-ca.crt")
+	assert.Error(test, err)
+
+	server = NewServer(false, false, "1M")
+	err = server.RequireClientCertificates("/etc/ssl/certs/ca-certificates.crt")
+	assert.Error(test, err)
+	assert.Equal(test, "The server must be created with TLS enabled to require client certificates", err.Error())
+	assert.Nil(test, server.clientCAs)
+}
+
+func TestRequireClientCertificatesReloadsClientCAs(test *testing.T) {
+	authority, err := tlstest.NewCertificateAuthority()
+	assert.NoError(test, err)
+
+	rotatedAuthority, err := tlstest.NewCertificateAuthority()
+	assert.NoError(test, err)
+
+	serverCertificate, err := authority.IssueServerCertificate("internal.example.com")
+	assert.NoError(test, err)
+	assert.NoError(test, serverCertificate.WriteFiles("/tmp/a-mutual-certificate.pem", "/tmp/a-mutual-key.pem"))
+	defer os.Remove("/tmp/a-mutual-certificate.pem")
+	defer os.Remove("/tmp/a-mutual-key.pem")
+
+	assert.NoError(test, authority.WriteCertificateFile("/tmp/a-client-ca.pem"))
+	defer os.Remove("/tmp/a-client-ca.pem")
+
+	server := NewServer(true, false, "1M")
+	assert.NoError(test, server.UseCertificateFiles("/tmp/a-mutual-certificate.pem", "/tmp/a-mutual-key.pem", 0))
+	assert.NoError(test, server.RequireClientCertificatesWithReloadInterval(0, "/tmp/a-client-ca.pem"))
+	defer server.clientCAs.Close()
+	server.GET("/", func(context echo.Context) error {
+		return context.String(http.StatusOK, GetPeerIdentityFromContext(context).CommonName)
+	})
+
+	testServer := httptest.NewUnstartedServer(server)
+	testServer.TLS = server.tlsConfig()
+	testServer.StartTLS()
+	defer testServer.Close()
+
+	get := func(clientCertificate *tlstest.IssuedCertificate) (response *http.Response, err error) {
+		config := clientCertificate.ClientTLSConfig()
+		config.RootCAs = authority.Pool()
+		config.ServerName = "internal.example.com"
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: config}}
+		response, err = client.Get(testServer.URL)
+		return
+	}
+
+	clientCertificate, err := authority.IssueClientCertificate("billing")
+	assert.NoError(test, err)
+
+	rotatedClientCertificate, err := rotatedAuthority.IssueClientCertificate("accounts")
+	assert.NoError(test, err)
+
+	response, err := get(clientCertificate)
+	assert.NoError(test, err)
+	defer response.Body.Close()
+	assert.Equal(test, http.StatusOK, response.StatusCode)
+
+	_, err = get(rotatedClientCertificate)
+	assert.Error(test, err)
+
+	assert.NoError(test, rotatedAuthority.WriteCertificateFile("/tmp/a-client-ca.pem"))
+	assert.NoError(test, server.clientCAs.Reload())
+
+	response, err = get(rotatedClientCertificate)
+	assert.NoError(test, err)
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	assert.NoError(test, err)
+	assert.Equal(test, http.StatusOK, response.StatusCode)
+	assert.Equal(test, "accounts", string(body))
+}
+
+func TestRequiredPeerMiddlewareOverTLS(test *testing.T) {
+	authority, err := tlstest.NewCertificateAuthority()
+	assert.NoError(test, err)
+
+	serverCertificate, err := authority.IssueServerCertificate("127.0.0.1")
+	assert.NoError(test, err)
+
+	clientCertificate, err := authority.IssueClientCertificate("billing", "spiffe://mojlighetsministeriet.se/service/billing")
+	assert.NoError(test, err)
+
+	otherClientCertificate, err := authority.IssueClientCertificate("accounts")
+	assert.NoError(test, err)
+
+	server := NewServer(false, false, "1M")
+	server.Use(PeerIdentityMiddleware())
+	server.GET("/", func(context echo.Context) error {
+		return context.String(http.StatusOK, GetPeerIdentityFromContext(context).SPIFFEID)
+	}, RequiredPeerMiddleware("spiffe://mojlighetsministeriet.se/service/billing"))
+
+	testServer := httptest.NewUnstartedServer(server)
+	testServer.TLS = serverCertificate.MutualServerTLSConfig()
+	testServer.StartTLS()
+	defer testServer.Close()
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: clientCertificate.ClientTLSConfig()}}
+	response, err := client.Get(testServer.URL)
+	assert.NoError(test, err)
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	assert.NoError(test, err)
+	assert.Equal(test, http.StatusOK, response.StatusCode)
+	assert.Equal(test, "spiffe://mojlighetsministeriet.se/service/billing", string(body))
+
+	client = &http.Client{Transport: &http.Transport{TLSClientConfig: otherClientCertificate.ClientTLSConfig()}}
+	response, err = client.Get(testServer.URL)
+	assert.NoError(test, err)
+	defer response.Body.Close()
+	assert.Equal(test, http.StatusForbidden, response.StatusCode)
+}
@@ -1,19 +1,28 @@
 package server
 
 import (
+	"crypto/tls"
+	"errors"
 	"net/http"
 	"sort"
 	"strings"
+	"time"
 
-	"github.com/gorilla/handlers"
 	"github.com/labstack/echo"
 	"github.com/labstack/echo/middleware"
+	"github.com/mojlighetsministeriet/utils"
+	"golang.org/x/crypto/acme"
 	"golang.org/x/crypto/acme/autocert"
 )
 
+// DefaultClientCAReloadInterval is how often RequireClientCertificates reloads the CAs that client certificates are verified against
+const DefaultClientCAReloadInterval = time.Minute
+
 type Server struct {
 	*echo.Echo
-	useTLS bool
+	useTLS      bool
+	clientCAs   *utils.TrustStoreReloader
+	certificate *utils.CertificateReloader
 }
 
 type Route struct {
@@ -51,12 +60,109 @@ func (server *Server) UseTLS() bool {
 
 func (server *Server) Listen(address string) {
 	server.addHelpResourceIfMissing()
+	server.Logger.Fatal(server.start(address))
+}
 
+func (server *Server) start(address string) error {
 	if server.useTLS {
-		server.Logger.Fatal(server.StartAutoTLS(address))
+		return server.startTLS(address)
+	}
+
+	return server.Start(address)
+}
+
+// RequireClientCertificates makes the TLS listener require client certificates signed by a CA in one of the paths (files, directories or globs, see utils.NewTrustStore).
+// The verified identity is available through GetPeerIdentityFromContext and routes can be restricted with RequiredPeerMiddleware.
+// The CAs are reloaded every DefaultClientCAReloadInterval, see RequireClientCertificatesWithReloadInterval.
+func (server *Server) RequireClientCertificates(caPaths ...string) (err error) {
+	err = server.RequireClientCertificatesWithReloadInterval(DefaultClientCAReloadInterval, caPaths...)
+	return
+}
+
+// RequireClientCertificatesWithReloadInterval works like RequireClientCertificates but reloads the CAs every reloadInterval so a rotated CA bundle is used
+// without a restart, a reloadInterval of 0 disables reloading.
+func (server *Server) RequireClientCertificatesWithReloadInterval(reloadInterval time.Duration, caPaths ...string) (err error) {
+	if !server.useTLS {
+		err = errors.New("The server must be created with TLS enabled to require client certificates")
+		return
+	}
+
+	clientCAs, err := utils.NewTrustStoreReloader(reloadInterval, false, caPaths...)
+	if err != nil {
+		return
+	}
+
+	if server.clientCAs != nil {
+		server.clientCAs.Close()
 	} else {
-		server.Logger.Fatal(server.Start(address))
+		server.Use(PeerIdentityMiddleware())
 	}
+
+	server.clientCAs = clientCAs
+
+	return
+}
+
+// UseCertificateFiles makes the TLS listener serve a PEM encoded certificate and key, e.g. issued by an internal CA, instead of requesting one from Let's Encrypt.
+// The files are checked for changes every reloadInterval so renewed certificates are served without a restart, a reloadInterval of 0 disables reloading.
+func (server *Server) UseCertificateFiles(certificateFilename, keyFilename string, reloadInterval time.Duration) (err error) {
+	if !server.useTLS {
+		err = errors.New("The server must be created with TLS enabled to use a certificate")
+		return
+	}
+
+	certificate, err := utils.NewCertificateReloader(certificateFilename, keyFilename, reloadInterval)
+	if err != nil {
+		return
+	}
+
+	if server.certificate != nil {
+		server.certificate.Close()
+	}
+
+	server.certificate = certificate
+
+	return
+}
+
+func (server *Server) startTLS(address string) error {
+	server.TLSServer.TLSConfig = server.tlsConfig()
+	server.TLSServer.Addr = address
+
+	return server.StartServer(server.TLSServer)
+}
+
+func (server *Server) tlsConfig() *tls.Config {
+	config := &tls.Config{GetCertificate: server.AutoTLSManager.GetCertificate}
+
+	if server.certificate != nil {
+		config.GetCertificate = server.certificate.GetCertificate
+	}
+
+	if !server.DisableHTTP2 {
+		config.NextProtos = append(config.NextProtos, "h2")
+	}
+
+	if server.certificate == nil {
+		// The ACME server validates the domain with a TLS-ALPN-01 handshake that the autocert manager answers
+		config.NextProtos = append(config.NextProtos, acme.ALPNProto)
+	}
+
+	config = utils.ApplyDefaultTLSProfile(config)
+
+	if server.clientCAs != nil {
+		// The client CAs are read for every handshake, the same way as utils.GetReloadingServerTLSConfig, so reloaded CAs are used right away
+		clientCAs := server.clientCAs
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+		config.GetConfigForClient = func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			handshakeConfig := config.Clone()
+			handshakeConfig.GetConfigForClient = nil
+			handshakeConfig.ClientCAs = clientCAs.Pool()
+			return handshakeConfig, nil
+		}
+	}
+
+	return config
 }
 
 func (server *Server) addHelpResourceIfMissing() {
@@ -88,10 +194,14 @@ func (server *Server) addHelpResourceIfMissing() {
 }
 
 func NewServer(useTLS bool, behindProxy bool, bodyLimit string) *Server {
-	server := Server{Echo: echo.New()}
+	server := Server{Echo: echo.New(), useTLS: useTLS}
 
 	if useTLS {
-		server.AutoTLSManager.Cache = autocert.DirCache("/var/www/.cache")
+		server.AutoTLSManager.Cache = autocert.DirCache(DefaultAutoTLSCacheDirectory)
+	}
+
+	if behindProxy {
+		server.Pre(TrustedProxyMiddleware())
 	}
 
 	server.Pre(middleware.NonWWWRedirect())
@@ -99,9 +209,7 @@ func NewServer(useTLS bool, behindProxy bool, bodyLimit string) *Server {
 	server.Use(middleware.Logger())
 	server.Use(middleware.BodyLimit(bodyLimit))
 
-	if behindProxy {
-		server.Use(echo.WrapMiddleware(handlers.ProxyHeaders))
-	} else {
+	if !behindProxy {
 		// Gzip causes problem for the proxied streamed requests, so mixin is disabled for now.
 		//server.Use(middleware.Gzip())
 		server.Use(middleware.CSRFWithConfig(middleware.CSRFConfig{
@@ -0,0 +1,149 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/mojlighetsministeriet/utils/tlstest"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/acme"
+)
+
+func TestNewServerWithTLSServesTLS(test *testing.T) {
+	assert.Equal(test, true, NewServer(true, false, "1M").UseTLS())
+	assert.Equal(test, false, NewServer(false, false, "1M").UseTLS())
+
+	authority, err := tlstest.NewCertificateAuthority()
+	assert.NoError(test, err)
+
+	certificate, err := authority.IssueServerCertificate("127.0.0.1")
+	assert.NoError(test, err)
+	assert.NoError(test, certificate.WriteFiles("/tmp/a-listening-certificate.pem", "/tmp/a-listening-key.pem"))
+	defer os.Remove("/tmp/a-listening-certificate.pem")
+	defer os.Remove("/tmp/a-listening-key.pem")
+
+	server := NewServer(true, false, "1M")
+	server.HideBanner = true
+	assert.NoError(test, server.UseCertificateFiles("/tmp/a-listening-certificate.pem", "/tmp/a-listening-key.pem", 0))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(test, err)
+	address := listener.Addr().String()
+	listener.Close()
+
+	go server.start(address)
+	defer server.Shutdown(context.Background())
+
+	var connection *tls.Conn
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		connection, err = tls.Dial("tcp", address, authority.ClientTLSConfig())
+		if err == nil {
+			break
+		}
+	}
+
+	assert.NoError(test, err)
+	if connection != nil {
+		assert.Equal(test, certificate.Certificate.Leaf.SerialNumber, connection.ConnectionState().PeerCertificates[0].SerialNumber)
+		connection.Close()
+	}
+}
+
+func TestUseCertificateFiles(test *testing.T) {
+	authority, err := tlstest.NewCertificateAuthority()
+	assert.NoError(test, err)
+
+	certificate, err := authority.IssueServerCertificate("internal.example.com")
+	assert.NoError(test, err)
+	assert.NoError(test, certificate.WriteFiles("/tmp/a-server-certificate.pem", "/tmp/a-server-key.pem"))
+	defer os.Remove("/tmp/a-server-certificate.pem")
+	defer os.Remove("/tmp/a-server-key.pem")
+
+	server := NewServer(true, false, "1M")
+	assert.NoError(test, server.UseCertificateFiles("/tmp/a-server-certificate.pem", "/tmp/a-server-key.pem", 10*time.Millisecond))
+	defer server.certificate.Close()
+	server.GET("/", func(context echo.Context) error {
+		return context.NoContent(http.StatusOK)
+	})
+
+	testServer := httptest.NewUnstartedServer(server)
+	testServer.TLS = server.tlsConfig()
+	testServer.StartTLS()
+	defer testServer.Close()
+
+	clientConfig := authority.ClientTLSConfig()
+	clientConfig.ServerName = "internal.example.com"
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: clientConfig}}
+
+	response, err := client.Get(testServer.URL)
+	assert.NoError(test, err)
+	defer response.Body.Close()
+	assert.Equal(test, http.StatusOK, response.StatusCode)
+	assert.Equal(test, "max-age=31536000; includeSubdomains", response.Header.Get(echo.HeaderStrictTransportSecurity))
+	assert.Equal(test, certificate.Certificate.Leaf.SerialNumber, response.TLS.PeerCertificates[0].SerialNumber)
+
+	renewedCertificate, err := authority.IssueServerCertificate("internal.example.com")
+	assert.NoError(test, err)
+	assert.NoError(test, renewedCertificate.WriteFiles("/tmp/a-server-certificate.pem", "/tmp/a-server-key.pem"))
+	assert.NoError(test, os.Chtimes("/tmp/a-server-certificate.pem", time.Now().Add(time.Minute), time.Now().Add(time.Minute)))
+	assert.NoError(test, os.Chtimes("/tmp/a-server-key.pem", time.Now().Add(time.Minute), time.Now().Add(time.Minute)))
+	time.Sleep(100 * time.Millisecond)
+
+	client = &http.Client{Transport: &http.Transport{TLSClientConfig: clientConfig}}
+	response, err = client.Get(testServer.URL)
+	assert.NoError(test, err)
+	defer response.Body.Close()
+	assert.Equal(test, renewedCertificate.Certificate.Leaf.SerialNumber, response.TLS.PeerCertificates[0].SerialNumber)
+}
+
+func TestTLSConfigAnswersACMEChallenges(test *testing.T) {
+	server := NewServer(true, false, "1M")
+	assert.Equal(test, []string{"h2", acme.ALPNProto}, server.tlsConfig().NextProtos)
+
+	server.DisableHTTP2 = true
+	assert.Equal(test, []string{acme.ALPNProto}, server.tlsConfig().NextProtos)
+
+	authority, err := tlstest.NewCertificateAuthority()
+	assert.NoError(test, err)
+
+	certificate, err := authority.IssueServerCertificate("internal.example.com")
+	assert.NoError(test, err)
+	assert.NoError(test, certificate.WriteFiles("/tmp/a-static-certificate.pem", "/tmp/a-static-key.pem"))
+	defer os.Remove("/tmp/a-static-certificate.pem")
+	defer os.Remove("/tmp/a-static-key.pem")
+
+	server = NewServer(true, false, "1M")
+	assert.NoError(test, server.UseCertificateFiles("/tmp/a-static-certificate.pem", "/tmp/a-static-key.pem", 0))
+	assert.Equal(test, []string{"h2"}, server.tlsConfig().NextProtos)
+}
+
+func TestUseCertificateFilesRedirectsToHTTPS(test *testing.T) {
+	server := NewServer(true, false, "1M")
+	server.GET("/", func(context echo.Context) error {
+		return context.NoContent(http.StatusOK)
+	})
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(echo.GET, "/", nil))
+	assert.Equal(test, http.StatusMovedPermanently, recorder.Code)
+	assert.Equal(test, "https://example.com/", recorder.Header().Get(echo.HeaderLocation))
+}
+
+func TestFailUseCertificateFiles(test *testing.T) {
+	server := NewServer(false, false, "1M")
+	err := server.UseCertificateFiles("/tmp/a-server-certificate.pem", "/tmp/a-server-key.pem", 0)
+	assert.Error(test, err)
+	assert.Equal(test, "The server must be created with TLS enabled to use a certificate", err.Error())
+
+	server = NewServer(true, false, "1M")
+	err = server.UseCertificateFiles("/tmp/a-missing-certificate.pem", "/tmp/a-missing-key.pem", 0)
+	assert.Error(test, err)
+	assert.Nil(test, server.certificate)
+}
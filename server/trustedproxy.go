@@ -0,0 +1,43 @@
+package server
+
+import (
+	"net/url"
+
+	"github.com/labstack/echo"
+	"github.com/mojlighetsministeriet/utils"
+)
+
+// TrustedProxyMiddleware replaces the forwarded headers of requests that come from a trusted proxy (see utils.SetTrustedProxies) with the values they describe
+// and removes them from other requests. X-Forwarded-For is set to the client found by utils.GetClientIP so that echo.Context.RealIP() can not be spoofed, and
+// X-Forwarded-Proto, X-Forwarded-Host and X-Forwarded-Prefix to the single values of the original URL so later handlers resolve the same URL.
+func TrustedProxyMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(context echo.Context) error {
+			request := context.Request()
+			clientIP := utils.GetClientIP(request)
+
+			if utils.RemoveUntrustedForwardedHeaders(request) {
+				originalURL, err := url.Parse(utils.GetOriginalSystemURLFromRequest(request))
+
+				for _, header := range utils.ForwardedHeaders {
+					request.Header.Del(header)
+				}
+
+				if err == nil {
+					request.Host = originalURL.Host
+					request.URL.Scheme = originalURL.Scheme
+					request.Header.Set("X-Forwarded-Proto", originalURL.Scheme)
+					request.Header.Set("X-Forwarded-Host", originalURL.Host)
+
+					if originalURL.Path != "" {
+						request.Header.Set("X-Forwarded-Prefix", originalURL.Path)
+					}
+				}
+			}
+
+			request.Header.Set("X-Forwarded-For", clientIP)
+
+			return next(context)
+		}
+	}
+}
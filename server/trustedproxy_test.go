@@ -0,0 +1,85 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/mojlighetsministeriet/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrustedProxyMiddleware(test *testing.T) {
+	utils.SetTrustedProxies(append(utils.DefaultTrustedProxies, utils.PrivateNetworks...)...)
+	defer utils.SetTrustedProxies(utils.DefaultTrustedProxies...)
+
+	server := NewServer(false, true, "1M")
+	server.GET("/", func(context echo.Context) error {
+		return context.JSON(http.StatusOK, map[string]string{
+			"realIP":  context.RealIP(),
+			"baseURL": utils.GetOriginalSystemURLFromContext(context),
+			"host":    context.Request().Host,
+		})
+	})
+
+	request := httptest.NewRequest(echo.GET, "/", nil)
+	request.RemoteAddr = "10.0.0.1:1234"
+	request.Header.Set("X-Forwarded-For", "203.0.113.99, 198.51.100.4")
+	request.Header.Set("X-Forwarded-Proto", "https")
+	request.Header.Set("X-Forwarded-Host", "internt.mojlighetsministeriet.se")
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+
+	assert.Equal(test, http.StatusOK, recorder.Code)
+	assert.Equal(test, "{\"baseURL\":\"https://internt.mojlighetsministeriet.se\",\"host\":\"internt.mojlighetsministeriet.se\",\"realIP\":\"198.51.100.4\"}\n", recorder.Body.String())
+}
+
+func TestTrustedProxyMiddlewareWithMultipleHops(test *testing.T) {
+	utils.SetTrustedProxies(append(utils.DefaultTrustedProxies, utils.PrivateNetworks...)...)
+	defer utils.SetTrustedProxies(utils.DefaultTrustedProxies...)
+
+	server := NewServer(false, true, "1M")
+	server.GET("/accounts/:id/reset-password", func(context echo.Context) error {
+		absoluteURL, err := server.AbsoluteURL(context, "resetPassword", nil, 42)
+		if err != nil {
+			return err
+		}
+
+		return context.String(http.StatusOK, absoluteURL)
+	}).Name = "resetPassword"
+
+	request := httptest.NewRequest(echo.GET, "/accounts/42/reset-password", nil)
+	request.RemoteAddr = "10.0.0.1:1234"
+	request.Header.Set("X-Forwarded-For", "203.0.113.9, 10.0.0.5")
+	request.Header.Set("X-Forwarded-Host", "internt.mojlighetsministeriet.se, internal")
+	request.Header.Set("X-Forwarded-Proto", "https, http")
+	request.Header.Set("X-Forwarded-Prefix", "/account, /")
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+
+	assert.Equal(test, http.StatusOK, recorder.Code)
+	assert.Equal(test, "https://internt.mojlighetsministeriet.se/account/accounts/42/reset-password", recorder.Body.String())
+}
+
+func TestTrustedProxyMiddlewareWithUntrustedPeer(test *testing.T) {
+	server := NewServer(false, true, "1M")
+	server.GET("/", func(context echo.Context) error {
+		return context.JSON(http.StatusOK, map[string]string{
+			"realIP":  context.RealIP(),
+			"baseURL": utils.GetOriginalSystemURLFromContext(context),
+			"scheme":  context.Scheme(),
+		})
+	})
+
+	request := httptest.NewRequest(echo.GET, "/", nil)
+	request.Header.Set("X-Forwarded-For", "203.0.113.99")
+	request.Header.Set("X-Real-Ip", "203.0.113.99")
+	request.Header.Set("X-Forwarded-Proto", "https")
+	request.Header.Set("X-Forwarded-Host", "attacker.example.com")
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+
+	assert.Equal(test, http.StatusOK, recorder.Code)
+	assert.Equal(test, "{\"baseURL\":\"http://example.com\",\"realIP\":\"192.0.2.1\",\"scheme\":\"http\"}\n", recorder.Body.String())
+}
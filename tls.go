@@ -3,25 +3,157 @@ package utils
 import (
 	"crypto/tls"
 	"crypto/x509"
-	"io/ioutil"
+	"errors"
+	"os"
 )
 
+// CACertificatesFilename is the CA bundle read by GetCACertificatesTLSConfig
+const CACertificatesFilename = "/etc/ssl/certs/ca-certificates.crt"
+
 // GetCACertificatesTLSConfig will read and return a configuration for the root certificates from /etc/ssl/certs/ca-certificates.crt that can be mounted from the host system.
 func GetCACertificatesTLSConfig() (config *tls.Config, err error) {
-	config, err = GetTLSConfigFromFilename("/etc/ssl/certs/ca-certificates.crt")
+	config, err = GetTLSConfigFromFilename(CACertificatesFilename)
 	return
 }
 
-// GetTLSConfigFromFilename will read and return a configuration for the certificates in a file
-func GetTLSConfigFromFilename(filename string) (config *tls.Config, err error) {
-	certs, err := ioutil.ReadFile(filename)
+// GetSystemCACertificatesTLSConfig works like GetCACertificatesTLSConfig but falls back to the system certificate pool when there is no CA bundle, e.g. in scratch or alpine images
+func GetSystemCACertificatesTLSConfig() (config *tls.Config, err error) {
+	_, err = os.Stat(CACertificatesFilename)
+	if err == nil {
+		config, err = GetCACertificatesTLSConfig()
+		return
+	}
+
+	if !os.IsNotExist(err) {
+		return
+	}
+
+	pool, err := x509.SystemCertPool()
 	if err != nil {
 		return
 	}
 
-	pool := x509.NewCertPool()
-	pool.AppendCertsFromPEM(certs)
 	config = &tls.Config{RootCAs: pool}
 
 	return
 }
+
+// GetTLSConfigFromFilename will read and return a configuration for the certificates in a file, an error is returned if the file contains no certificates
+func GetTLSConfigFromFilename(filename string) (config *tls.Config, err error) {
+	store, err := NewTrustStore(false, filename)
+	if err != nil {
+		return
+	}
+
+	config = store.TLSConfig()
+
+	return
+}
+
+// GetClientCertificateTLSConfig returns the configuration from GetSystemCACertificatesTLSConfig with a client certificate for mutual TLS read from PEM encoded files.
+// Use GetClientCertificateTLSConfigWithRootCAs to trust an internal CA instead of the public ones.
+func GetClientCertificateTLSConfig(certificateFilename, keyFilename string) (config *tls.Config, err error) {
+	certificate, err := tls.LoadX509KeyPair(certificateFilename, keyFilename)
+	if err != nil {
+		return
+	}
+
+	config, err = getClientCertificateTLSConfig(certificate)
+
+	return
+}
+
+// GetClientCertificateTLSConfigFromPEM works like GetClientCertificateTLSConfig but takes the PEM encoded certificate and key from memory
+func GetClientCertificateTLSConfigFromPEM(certificatePEM, keyPEM []byte) (config *tls.Config, err error) {
+	certificate, err := tls.X509KeyPair(certificatePEM, keyPEM)
+	if err != nil {
+		return
+	}
+
+	config, err = getClientCertificateTLSConfig(certificate)
+
+	return
+}
+
+// GetClientCertificateTLSConfigWithRootCAs returns a configuration for mutual TLS with a client certificate, e.g. from tls.LoadX509KeyPair, that only trusts the servers
+// signed by rootCAs, e.g. the Pool of a TrustStore for an internal CA, so no public CA bundle is needed
+func GetClientCertificateTLSConfigWithRootCAs(certificate tls.Certificate, rootCAs *x509.CertPool) *tls.Config {
+	return &tls.Config{RootCAs: rootCAs, Certificates: []tls.Certificate{certificate}}
+}
+
+func getClientCertificateTLSConfig(certificate tls.Certificate) (config *tls.Config, err error) {
+	config, err = GetSystemCACertificatesTLSConfig()
+	if err != nil {
+		return
+	}
+
+	config.Certificates = []tls.Certificate{certificate}
+
+	return
+}
+
+// GetReloadingServerTLSConfig returns a server configuration that always serves the current certificate from the reloader.
+// If clientCAs is not nil, client certificates are required and verified against the current trust store. The default TLS profile is applied.
+func GetReloadingServerTLSConfig(certificate *CertificateReloader, clientCAs *TrustStoreReloader) *tls.Config {
+	config := ApplyDefaultTLSProfile(&tls.Config{GetCertificate: certificate.GetCertificate})
+
+	if clientCAs != nil {
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+		config.GetConfigForClient = func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			handshakeConfig := config.Clone()
+			handshakeConfig.GetConfigForClient = nil
+			handshakeConfig.ClientCAs = clientCAs.Pool()
+			return handshakeConfig, nil
+		}
+	}
+
+	return config
+}
+
+// GetReloadingClientTLSConfig returns a client configuration that presents the current certificate from the reloader, if not nil, and
+// verifies servers against the current trust store. Since tls.Config.RootCAs can not change, the server certificate is verified in VerifyConnection instead,
+// against the server name that was sent or, when connecting to an IP address, the ServerName of the returned config. Connections without a name to verify fail.
+// If rootCAs is nil servers are verified against the system pool as usual. The default TLS profile is applied.
+func GetReloadingClientTLSConfig(certificate *CertificateReloader, rootCAs *TrustStoreReloader) *tls.Config {
+	config := ApplyDefaultTLSProfile(nil)
+
+	if certificate != nil {
+		config.GetClientCertificate = certificate.GetClientCertificate
+	}
+
+	if rootCAs == nil {
+		return config
+	}
+
+	config.InsecureSkipVerify = true
+	config.VerifyConnection = func(state tls.ConnectionState) error {
+		// No server name indication is sent for IP addresses so state.ServerName is empty
+		return verifyServerCertificate(state, firstNonEmpty(state.ServerName, config.ServerName), rootCAs.Pool())
+	}
+
+	return config
+}
+
+func verifyServerCertificate(state tls.ConnectionState, serverName string, roots *x509.CertPool) (err error) {
+	if len(state.PeerCertificates) == 0 {
+		return errors.New("The server did not present a certificate")
+	}
+
+	if serverName == "" {
+		return errors.New("The server certificate can not be verified without a server name, set ServerName when connecting to an IP address")
+	}
+
+	options := x509.VerifyOptions{
+		Roots:         roots,
+		DNSName:       serverName,
+		Intermediates: x509.NewCertPool(),
+	}
+
+	for _, intermediate := range state.PeerCertificates[1:] {
+		options.Intermediates.AddCert(intermediate)
+	}
+
+	_, err = state.PeerCertificates[0].Verify(options)
+
+	return
+}
@@ -2,9 +2,14 @@ package utils_test
 
 import (
 	"crypto/tls"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
 	"testing"
 
 	"github.com/mojlighetsministeriet/utils"
+	"github.com/mojlighetsministeriet/utils/tlstest"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -26,3 +31,76 @@ func TestFailGetCACertificatesTLSConfigFromFilenameWithBadFilename(test *testing
 	assert.Error(test, err)
 	assert.Equal(test, expectedOutput, config)
 }
+
+func TestGetSystemCACertificatesTLSConfig(test *testing.T) {
+	config, err := utils.GetSystemCACertificatesTLSConfig()
+	assert.NoError(test, err)
+	assert.Equal(test, true, len(config.RootCAs.Subjects()) > 10)
+}
+
+func TestGetClientCertificateTLSConfigWithRootCAs(test *testing.T) {
+	authority, err := tlstest.NewCertificateAuthority()
+	assert.NoError(test, err)
+
+	serverCertificate, err := authority.IssueServerCertificate("127.0.0.1")
+	assert.NoError(test, err)
+
+	clientCertificate, err := authority.IssueClientCertificate("a-client")
+	assert.NoError(test, err)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Write([]byte(request.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	server.TLS = serverCertificate.MutualServerTLSConfig()
+	server.StartTLS()
+	defer server.Close()
+
+	config := utils.GetClientCertificateTLSConfigWithRootCAs(clientCertificate.Certificate, authority.Pool())
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: config}}
+
+	response, err := client.Get(server.URL)
+	assert.NoError(test, err)
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	assert.NoError(test, err)
+	assert.Equal(test, "a-client", string(body))
+}
+
+func TestGetClientCertificateTLSConfig(test *testing.T) {
+	authority, err := tlstest.NewCertificateAuthority()
+	assert.NoError(test, err)
+
+	clientCertificate, err := authority.IssueClientCertificate("a-client")
+	assert.NoError(test, err)
+	assert.NoError(test, clientCertificate.WriteFiles("/tmp/a-client-certificate.pem", "/tmp/a-client-key.pem"))
+	defer os.Remove("/tmp/a-client-certificate.pem")
+	defer os.Remove("/tmp/a-client-key.pem")
+
+	config, err := utils.GetClientCertificateTLSConfig("/tmp/a-client-certificate.pem", "/tmp/a-client-key.pem")
+	assert.NoError(test, err)
+	assert.Equal(test, 1, len(config.Certificates))
+	assert.Equal(test, true, len(config.RootCAs.Subjects()) > 10)
+
+	config, err = utils.GetClientCertificateTLSConfigFromPEM(clientCertificate.CertificatePEM, clientCertificate.KeyPEM)
+	assert.NoError(test, err)
+	assert.Equal(test, 1, len(config.Certificates))
+}
+
+func TestFailGetClientCertificateTLSConfigWithMismatchedKey(test *testing.T) {
+	authority, err := tlstest.NewCertificateAuthority()
+	assert.NoError(test, err)
+
+	clientCertificate, err := authority.IssueClientCertificate("a-client")
+	assert.NoError(test, err)
+
+	anotherClientCertificate, err := authority.IssueClientCertificate("another-client")
+	assert.NoError(test, err)
+
+	config, err := utils.GetClientCertificateTLSConfigFromPEM(clientCertificate.CertificatePEM, anotherClientCertificate.KeyPEM)
+	assert.Error(test, err)
+	assert.Nil(test, config)
+
+	_, err = utils.GetClientCertificateTLSConfig("/tmp/a-missing-certificate.pem", "/tmp/a-missing-key.pem")
+	assert.Error(test, err)
+}
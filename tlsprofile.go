@@ -0,0 +1,122 @@
+package utils
+
+import (
+	"crypto/tls"
+	"errors"
+	"sync"
+)
+
+// TLSProfile is a named set of TLS settings that can be applied to both servers and clients
+type TLSProfile struct {
+	Name                   string
+	MinVersion             uint16
+	CipherSuites           []uint16
+	CurvePreferences       []tls.CurveID
+	SessionTicketsDisabled bool
+}
+
+// TLSProfileModern only allows TLS 1.3, which has no configurable cipher suites, for services that only talk to up to date clients
+var TLSProfileModern = TLSProfile{
+	Name:             "modern",
+	MinVersion:       tls.VersionTLS13,
+	CurvePreferences: []tls.CurveID{tls.X25519, tls.CurveP256, tls.CurveP384},
+}
+
+// TLSProfileIntermediate allows TLS 1.2 with forward secret AEAD cipher suites and TLS 1.3, it is the default profile
+var TLSProfileIntermediate = TLSProfile{
+	Name:       "intermediate",
+	MinVersion: tls.VersionTLS12,
+	CipherSuites: []uint16{
+		tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+		tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+		tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+		tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+		tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305,
+		tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,
+	},
+	CurvePreferences: []tls.CurveID{tls.X25519, tls.CurveP256, tls.CurveP384},
+}
+
+// TLSProfileInternalStrict only allows TLS 1.3 and disables session tickets so every connection between internal services makes a full handshake
+var TLSProfileInternalStrict = TLSProfile{
+	Name:                   "internal-strict",
+	MinVersion:             tls.VersionTLS13,
+	CurvePreferences:       []tls.CurveID{tls.X25519, tls.CurveP256},
+	SessionTicketsDisabled: true,
+}
+
+var tlsProfiles = []TLSProfile{TLSProfileModern, TLSProfileIntermediate, TLSProfileInternalStrict}
+
+var defaultTLSProfile = struct {
+	sync.RWMutex
+	profile TLSProfile
+}{profile: TLSProfileIntermediate}
+
+// GetTLSProfile returns the profile named modern, intermediate or internal-strict
+func GetTLSProfile(name string) (profile TLSProfile, err error) {
+	for _, candidate := range tlsProfiles {
+		if candidate.Name == name {
+			profile = candidate
+			return
+		}
+	}
+
+	err = errors.New("Unknown TLS profile " + name + ", use modern, intermediate or internal-strict")
+
+	return
+}
+
+// SetDefaultTLSProfile changes the profile used by ApplyDefaultTLSProfile, e.g. SetDefaultTLSProfile(GetEnv("TLS_PROFILE", "intermediate")).
+// It should be called during startup before any server or client is created.
+func SetDefaultTLSProfile(name string) (err error) {
+	profile, err := GetTLSProfile(name)
+	if err != nil {
+		return
+	}
+
+	defaultTLSProfile.Lock()
+	defaultTLSProfile.profile = profile
+	defaultTLSProfile.Unlock()
+
+	return
+}
+
+// GetDefaultTLSProfile returns the profile used by ApplyDefaultTLSProfile
+func GetDefaultTLSProfile() TLSProfile {
+	defaultTLSProfile.RLock()
+	defer defaultTLSProfile.RUnlock()
+
+	return defaultTLSProfile.profile
+}
+
+// ApplyDefaultTLSProfile returns a copy of config with the default profile applied, it is used by the server listener, the proxy and the httprequest clients
+func ApplyDefaultTLSProfile(config *tls.Config) *tls.Config {
+	return GetDefaultTLSProfile().Apply(config)
+}
+
+// Apply returns a copy of config, or a new config if it is nil, with the settings of the profile. The profile never makes a config less strict, the minimum version
+// is only raised and cipher suites, curves and disabled session tickets that are already set are kept, so e.g. a client configured with TLSProfileInternalStrict.Apply
+// keeps its settings when ApplyDefaultTLSProfile is applied to it again.
+func (profile TLSProfile) Apply(config *tls.Config) *tls.Config {
+	if config == nil {
+		config = &tls.Config{}
+	} else {
+		config = config.Clone()
+	}
+
+	if config.MinVersion < profile.MinVersion {
+		config.MinVersion = profile.MinVersion
+	}
+
+	if len(config.CipherSuites) == 0 {
+		config.CipherSuites = append([]uint16(nil), profile.CipherSuites...)
+	}
+
+	if len(config.CurvePreferences) == 0 {
+		config.CurvePreferences = append([]tls.CurveID(nil), profile.CurvePreferences...)
+	}
+
+	config.SessionTicketsDisabled = config.SessionTicketsDisabled || profile.SessionTicketsDisabled
+
+	return config
+}
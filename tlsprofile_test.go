@@ -0,0 +1,79 @@
+package utils_test
+
+import (
+	"crypto/tls"
+	"testing"
+
+	"github.com/mojlighetsministeriet/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetTLSProfile(test *testing.T) {
+	profile, err := utils.GetTLSProfile("modern")
+	assert.NoError(test, err)
+	assert.Equal(test, uint16(tls.VersionTLS13), profile.MinVersion)
+
+	profile, err = utils.GetTLSProfile("intermediate")
+	assert.NoError(test, err)
+	assert.Equal(test, uint16(tls.VersionTLS12), profile.MinVersion)
+	assert.Equal(test, 6, len(profile.CipherSuites))
+
+	profile, err = utils.GetTLSProfile("internal-strict")
+	assert.NoError(test, err)
+	assert.Equal(test, true, profile.SessionTicketsDisabled)
+}
+
+func TestFailGetTLSProfileWithUnknownName(test *testing.T) {
+	_, err := utils.GetTLSProfile("legacy")
+	assert.Error(test, err)
+	assert.Equal(test, "Unknown TLS profile legacy, use modern, intermediate or internal-strict", err.Error())
+
+	err = utils.SetDefaultTLSProfile("legacy")
+	assert.Error(test, err)
+	assert.Equal(test, "intermediate", utils.GetDefaultTLSProfile().Name)
+}
+
+func TestTLSProfileApply(test *testing.T) {
+	original := &tls.Config{ServerName: "internal.example.com", MinVersion: tls.VersionTLS10}
+
+	config := utils.TLSProfileInternalStrict.Apply(original)
+	assert.Equal(test, "internal.example.com", config.ServerName)
+	assert.Equal(test, uint16(tls.VersionTLS13), config.MinVersion)
+	assert.Equal(test, []tls.CurveID{tls.X25519, tls.CurveP256}, config.CurvePreferences)
+	assert.Equal(test, true, config.SessionTicketsDisabled)
+	assert.Equal(test, uint16(tls.VersionTLS10), original.MinVersion)
+
+	config = utils.TLSProfileIntermediate.Apply(nil)
+	assert.Equal(test, uint16(tls.VersionTLS12), config.MinVersion)
+	assert.Equal(test, utils.TLSProfileIntermediate.CipherSuites, config.CipherSuites)
+}
+
+func TestTLSProfileApplyKeepsStricterSettings(test *testing.T) {
+	original := &tls.Config{
+		MinVersion:             tls.VersionTLS13,
+		CipherSuites:           []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384},
+		CurvePreferences:       []tls.CurveID{tls.X25519},
+		SessionTicketsDisabled: true,
+	}
+
+	config := utils.TLSProfileIntermediate.Apply(original)
+	assert.Equal(test, uint16(tls.VersionTLS13), config.MinVersion)
+	assert.Equal(test, []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384}, config.CipherSuites)
+	assert.Equal(test, []tls.CurveID{tls.X25519}, config.CurvePreferences)
+	assert.Equal(test, true, config.SessionTicketsDisabled)
+
+	config = utils.ApplyDefaultTLSProfile(utils.TLSProfileInternalStrict.Apply(nil))
+	assert.Equal(test, uint16(tls.VersionTLS13), config.MinVersion)
+	assert.Equal(test, []tls.CurveID{tls.X25519, tls.CurveP256}, config.CurvePreferences)
+	assert.Equal(test, true, config.SessionTicketsDisabled)
+}
+
+func TestSetDefaultTLSProfile(test *testing.T) {
+	defer utils.SetDefaultTLSProfile("intermediate")
+
+	assert.Equal(test, uint16(tls.VersionTLS12), utils.ApplyDefaultTLSProfile(nil).MinVersion)
+
+	assert.NoError(test, utils.SetDefaultTLSProfile("modern"))
+	assert.Equal(test, "modern", utils.GetDefaultTLSProfile().Name)
+	assert.Equal(test, uint16(tls.VersionTLS13), utils.ApplyDefaultTLSProfile(nil).MinVersion)
+}
@@ -0,0 +1,221 @@
+package tlstest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// DefaultValidity is how long certificates issued by a CertificateAuthority are valid
+const DefaultValidity = 24 * time.Hour
+
+// CertificateAuthority is an ephemeral certificate authority that lives in memory and issues certificates for tests
+type CertificateAuthority struct {
+	Certificate    *x509.Certificate
+	CertificatePEM []byte
+	Validity       time.Duration
+	privateKey     *ecdsa.PrivateKey
+	mutex          sync.Mutex
+	serialNumber   int64
+}
+
+// IssuedCertificate is a certificate and its private key issued by a CertificateAuthority
+type IssuedCertificate struct {
+	Certificate    tls.Certificate
+	CertificatePEM []byte
+	KeyPEM         []byte
+	authority      *CertificateAuthority
+}
+
+// NewCertificateAuthority creates a self signed certificate authority with a new private key
+func NewCertificateAuthority() (authority *CertificateAuthority, err error) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "tlstest certificate authority"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(DefaultValidity),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &privateKey.PublicKey, privateKey)
+	if err != nil {
+		return
+	}
+
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		return
+	}
+
+	authority = &CertificateAuthority{
+		Certificate:    certificate,
+		CertificatePEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		Validity:       DefaultValidity,
+		privateKey:     privateKey,
+		serialNumber:   1,
+	}
+
+	return
+}
+
+// Pool returns a certificate pool that only trusts the certificate authority
+func (authority *CertificateAuthority) Pool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(authority.Certificate)
+	return pool
+}
+
+// ClientTLSConfig returns a client configuration that trusts servers with certificates issued by the certificate authority
+func (authority *CertificateAuthority) ClientTLSConfig() *tls.Config {
+	return &tls.Config{RootCAs: authority.Pool()}
+}
+
+// WriteCertificateFile writes the PEM encoded certificate of the authority to filename, e.g. to be read by GetTLSConfigFromFilename
+func (authority *CertificateAuthority) WriteCertificateFile(filename string) error {
+	return ioutil.WriteFile(filename, authority.CertificatePEM, 0600)
+}
+
+// IssueServerCertificate issues a server certificate for hosts, a host that is an IP address is added as an IP SAN and the rest as DNS names
+func (authority *CertificateAuthority) IssueServerCertificate(hosts ...string) (issued *IssuedCertificate, err error) {
+	if len(hosts) == 0 {
+		err = errors.New("A server certificate requires at least one host")
+		return
+	}
+
+	template := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: hosts[0]},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	issued, err = authority.issue(template)
+
+	return
+}
+
+// IssueClientCertificate issues a client certificate with commonName as subject and uris, such as spiffe://example.com/service, as URI SANs
+func (authority *CertificateAuthority) IssueClientCertificate(commonName string, uris ...string) (issued *IssuedCertificate, err error) {
+	template := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: commonName},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	for _, uri := range uris {
+		var parsedURI *url.URL
+		parsedURI, err = url.Parse(uri)
+		if err != nil {
+			return
+		}
+
+		template.URIs = append(template.URIs, parsedURI)
+	}
+
+	issued, err = authority.issue(template)
+
+	return
+}
+
+func (authority *CertificateAuthority) issue(template *x509.Certificate) (issued *IssuedCertificate, err error) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return
+	}
+
+	authority.mutex.Lock()
+	authority.serialNumber++
+	template.SerialNumber = big.NewInt(authority.serialNumber)
+	authority.mutex.Unlock()
+
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(authority.Validity)
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+
+	der, err := x509.CreateCertificate(rand.Reader, template, authority.Certificate, &privateKey.PublicKey, authority.privateKey)
+	if err != nil {
+		return
+	}
+
+	key, err := x509.MarshalECPrivateKey(privateKey)
+	if err != nil {
+		return
+	}
+
+	certificatePEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: key})
+
+	certificate, err := tls.X509KeyPair(certificatePEM, keyPEM)
+	if err != nil {
+		return
+	}
+
+	certificate.Leaf, err = x509.ParseCertificate(der)
+	if err != nil {
+		return
+	}
+
+	issued = &IssuedCertificate{
+		Certificate:    certificate,
+		CertificatePEM: certificatePEM,
+		KeyPEM:         keyPEM,
+		authority:      authority,
+	}
+
+	return
+}
+
+// ServerTLSConfig returns a server configuration that serves the certificate
+func (issued *IssuedCertificate) ServerTLSConfig() *tls.Config {
+	return &tls.Config{Certificates: []tls.Certificate{issued.Certificate}}
+}
+
+// MutualServerTLSConfig returns a server configuration that serves the certificate and requires client certificates issued by the same certificate authority
+func (issued *IssuedCertificate) MutualServerTLSConfig() *tls.Config {
+	config := issued.ServerTLSConfig()
+	config.ClientAuth = tls.RequireAndVerifyClientCert
+	config.ClientCAs = issued.authority.Pool()
+	return config
+}
+
+// ClientTLSConfig returns a client configuration that presents the certificate and trusts servers with certificates issued by the same certificate authority
+func (issued *IssuedCertificate) ClientTLSConfig() *tls.Config {
+	config := issued.authority.ClientTLSConfig()
+	config.Certificates = []tls.Certificate{issued.Certificate}
+	return config
+}
+
+// WriteFiles writes the PEM encoded certificate and private key, e.g. to be read by GetClientCertificateTLSConfig or NewCertificateReloader
+func (issued *IssuedCertificate) WriteFiles(certificateFilename, keyFilename string) (err error) {
+	err = ioutil.WriteFile(certificateFilename, issued.CertificatePEM, 0600)
+	if err != nil {
+		return
+	}
+
+	err = ioutil.WriteFile(keyFilename, issued.KeyPEM, 0600)
+
+	return
+}
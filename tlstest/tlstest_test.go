@@ -0,0 +1,99 @@
+package tlstest_test
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/mojlighetsministeriet/utils"
+	"github.com/mojlighetsministeriet/utils/tlstest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIssueServerCertificate(test *testing.T) {
+	authority, err := tlstest.NewCertificateAuthority()
+	assert.NoError(test, err)
+
+	issued, err := authority.IssueServerCertificate("internal.example.com", "127.0.0.1")
+	assert.NoError(test, err)
+	assert.Equal(test, "internal.example.com", issued.Certificate.Leaf.Subject.CommonName)
+	assert.Equal(test, []string{"internal.example.com"}, issued.Certificate.Leaf.DNSNames)
+	assert.Equal(test, "127.0.0.1", issued.Certificate.Leaf.IPAddresses[0].String())
+
+	_, err = authority.IssueServerCertificate()
+	assert.Error(test, err)
+	assert.Equal(test, "A server certificate requires at least one host", err.Error())
+}
+
+func TestIssueClientCertificate(test *testing.T) {
+	authority, err := tlstest.NewCertificateAuthority()
+	assert.NoError(test, err)
+
+	issued, err := authority.IssueClientCertificate("billing", "spiffe://mojlighetsministeriet.se/service/billing")
+	assert.NoError(test, err)
+	assert.Equal(test, "billing", issued.Certificate.Leaf.Subject.CommonName)
+	assert.Equal(test, "spiffe://mojlighetsministeriet.se/service/billing", issued.Certificate.Leaf.URIs[0].String())
+}
+
+func TestMutualTLS(test *testing.T) {
+	authority, err := tlstest.NewCertificateAuthority()
+	assert.NoError(test, err)
+
+	serverCertificate, err := authority.IssueServerCertificate("127.0.0.1")
+	assert.NoError(test, err)
+
+	clientCertificate, err := authority.IssueClientCertificate("billing")
+	assert.NoError(test, err)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Write([]byte(request.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	server.TLS = serverCertificate.MutualServerTLSConfig()
+	server.StartTLS()
+	defer server.Close()
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: clientCertificate.ClientTLSConfig()}}
+	response, err := client.Get(server.URL)
+	assert.NoError(test, err)
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	assert.NoError(test, err)
+	assert.Equal(test, "billing", string(body))
+
+	client = &http.Client{Transport: &http.Transport{TLSClientConfig: authority.ClientTLSConfig()}}
+	_, err = client.Get(server.URL)
+	assert.Error(test, err)
+
+	otherAuthority, err := tlstest.NewCertificateAuthority()
+	assert.NoError(test, err)
+
+	client = &http.Client{Transport: &http.Transport{TLSClientConfig: otherAuthority.ClientTLSConfig()}}
+	_, err = client.Get(server.URL)
+	assert.Error(test, err)
+}
+
+func TestWriteFiles(test *testing.T) {
+	authority, err := tlstest.NewCertificateAuthority()
+	assert.NoError(test, err)
+
+	issued, err := authority.IssueClientCertificate("billing")
+	assert.NoError(test, err)
+
+	assert.NoError(test, authority.WriteCertificateFile("/tmp/tlstest-ca.pem"))
+	assert.NoError(test, issued.WriteFiles("/tmp/tlstest-certificate.pem", "/tmp/tlstest-key.pem"))
+	defer os.Remove("/tmp/tlstest-ca.pem")
+	defer os.Remove("/tmp/tlstest-certificate.pem")
+	defer os.Remove("/tmp/tlstest-key.pem")
+
+	config, err := utils.GetTLSConfigFromFilename("/tmp/tlstest-ca.pem")
+	assert.NoError(test, err)
+	assert.Equal(test, 1, len(config.RootCAs.Subjects()))
+
+	certificate, err := tls.LoadX509KeyPair("/tmp/tlstest-certificate.pem", "/tmp/tlstest-key.pem")
+	assert.NoError(test, err)
+	assert.Equal(test, issued.Certificate.Certificate, certificate.Certificate)
+}
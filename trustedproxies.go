@@ -0,0 +1,202 @@
+package utils
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// DefaultTrustedProxies are the networks that forwarded headers are accepted from unless SetTrustedProxies is called, only loopback
+var DefaultTrustedProxies = []string{"127.0.0.0/8", "::1/128"}
+
+// PrivateNetworks are the private IPv4 ranges and IPv6 unique local addresses. They are not trusted by default since any host on them could otherwise spoof
+// forwarded headers, services behind a proxy on a private network can trust them with SetTrustedProxies(append(DefaultTrustedProxies, PrivateNetworks...)...).
+var PrivateNetworks = []string{"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "fc00::/7"}
+
+// ForwardedHeaders are the headers that are removed from requests that do not come from a trusted proxy
+var ForwardedHeaders = []string{
+	"Forwarded",
+	"X-Forwarded-For",
+	"X-Forwarded-Host",
+	"X-Forwarded-Proto",
+	"X-Forwarded-Protocol",
+	"X-Forwarded-Ssl",
+	"X-Forwarded-Port",
+	"X-Forwarded-Prefix",
+	"X-Real-Ip",
+	"X-Url-Scheme",
+}
+
+var trustedProxies = struct {
+	sync.RWMutex
+	networks []*net.IPNet
+}{networks: mustParseCIDRs(DefaultTrustedProxies)}
+
+// SetTrustedProxies replaces the networks, in CIDR notation such as 10.0.0.0/8 or a single IP address, that forwarded headers are accepted from.
+// Calling it without arguments trusts no proxies.
+func SetTrustedProxies(cidrs ...string) (err error) {
+	networks, err := parseCIDRs(cidrs)
+	if err != nil {
+		return
+	}
+
+	trustedProxies.Lock()
+	trustedProxies.networks = networks
+	trustedProxies.Unlock()
+
+	return
+}
+
+// GetTrustedProxies returns the networks that forwarded headers are accepted from
+func GetTrustedProxies() (cidrs []string) {
+	trustedProxies.RLock()
+	defer trustedProxies.RUnlock()
+
+	cidrs = []string{}
+	for _, network := range trustedProxies.networks {
+		cidrs = append(cidrs, network.String())
+	}
+
+	return
+}
+
+// IsTrustedProxy tells if address, an IP address with or without port such as http.Request.RemoteAddr, belongs to a trusted proxy
+func IsTrustedProxy(address string) bool {
+	ip := net.ParseIP(stripPort(address))
+	if ip == nil {
+		return false
+	}
+
+	trustedProxies.RLock()
+	defer trustedProxies.RUnlock()
+
+	for _, network := range trustedProxies.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetClientIP returns the IP address of the client that made the request. Forwarded for addresses are only used if the request comes from a trusted proxy
+// and are read from right to left, skipping trusted proxies, so that a client can not pretend to be someone else by sending its own X-Forwarded-For header.
+func GetClientIP(request *http.Request) string {
+	ip := stripPort(request.RemoteAddr)
+	if !IsTrustedProxy(ip) {
+		return ip
+	}
+
+	forwardedFor := getForwardedForAddresses(request.Header)
+	if len(forwardedFor) == 0 {
+		if realIP := strings.TrimSpace(request.Header.Get("X-Real-Ip")); realIP != "" {
+			return stripPort(realIP)
+		}
+
+		return ip
+	}
+
+	return forwardedFor[len(forwardedFor)-1-getTrustedHops(forwardedFor)]
+}
+
+// RemoveUntrustedForwardedHeaders removes the ForwardedHeaders from a request that does not come from a trusted proxy and tells if they were trusted
+func RemoveUntrustedForwardedHeaders(request *http.Request) (trusted bool) {
+	trusted = IsTrustedProxy(request.RemoteAddr)
+	if !trusted {
+		for _, header := range ForwardedHeaders {
+			request.Header.Del(header)
+		}
+	}
+
+	return
+}
+
+func getForwardedForAddresses(headers http.Header) (addresses []string) {
+	if forwarded := strings.Join(headers["Forwarded"], ","); forwarded != "" {
+		for _, element := range splitOutsideQuotes(forwarded, ',') {
+			if address := parseForwardedElement(element)["for"]; address != "" {
+				addresses = append(addresses, stripPort(address))
+			}
+		}
+
+		return
+	}
+
+	addresses = getXForwardedForAddresses(headers)
+
+	return
+}
+
+func getXForwardedForAddresses(headers http.Header) (addresses []string) {
+	for _, address := range splitHeaderValues(headers, "X-Forwarded-For") {
+		addresses = append(addresses, stripPort(address))
+	}
+
+	return
+}
+
+// getTrustedHops counts the trusted proxies at the end of addresses, the address before them is the one the closest trusted proxy saw.
+// If every address is trusted the first one is used.
+func getTrustedHops(addresses []string) (hops int) {
+	for index := len(addresses) - 1; index > 0; index-- {
+		if !IsTrustedProxy(addresses[index]) {
+			return
+		}
+
+		hops++
+	}
+
+	return
+}
+
+// stripPort removes the port from addresses such as 10.0.0.1:1234 and [2001:db8::1]:1234 as well as the brackets from [2001:db8::1]
+func stripPort(address string) string {
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		return host
+	}
+
+	return strings.Trim(address, "[]")
+}
+
+func parseCIDRs(cidrs []string) (networks []*net.IPNet, err error) {
+	networks = []*net.IPNet{}
+
+	for _, cidr := range cidrs {
+		cidr = strings.TrimSpace(cidr)
+
+		if !strings.Contains(cidr, "/") {
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				err = errors.New("Unable to parse trusted proxy " + cidr + " as CIDR or IP address")
+				return
+			}
+
+			bits := 128
+			if ip.To4() != nil {
+				bits = 32
+			}
+
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		var network *net.IPNet
+		_, network, err = net.ParseCIDR(cidr)
+		if err != nil {
+			err = errors.New("Unable to parse trusted proxy " + cidr + " as CIDR or IP address")
+			return
+		}
+
+		networks = append(networks, network)
+	}
+
+	return
+}
+
+func mustParseCIDRs(cidrs []string) []*net.IPNet {
+	networks, err := parseCIDRs(cidrs)
+	panicIfError(err)
+	return networks
+}
@@ -0,0 +1,105 @@
+package utils_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/mojlighetsministeriet/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsTrustedProxy(test *testing.T) {
+	assert.Equal(test, true, utils.IsTrustedProxy("127.0.0.1:1234"))
+	assert.Equal(test, true, utils.IsTrustedProxy("[::1]:1234"))
+	assert.Equal(test, false, utils.IsTrustedProxy("10.1.2.3"))
+	assert.Equal(test, false, utils.IsTrustedProxy("fd00::1"))
+	assert.Equal(test, false, utils.IsTrustedProxy("192.0.2.1:1234"))
+	assert.Equal(test, false, utils.IsTrustedProxy("not an address"))
+}
+
+func TestTrustPrivateNetworks(test *testing.T) {
+	defer utils.SetTrustedProxies(utils.DefaultTrustedProxies...)
+
+	err := utils.SetTrustedProxies(append(utils.DefaultTrustedProxies, utils.PrivateNetworks...)...)
+	assert.NoError(test, err)
+	assert.Equal(test, true, utils.IsTrustedProxy("127.0.0.1:1234"))
+	assert.Equal(test, true, utils.IsTrustedProxy("10.1.2.3"))
+	assert.Equal(test, true, utils.IsTrustedProxy("172.16.0.1"))
+	assert.Equal(test, true, utils.IsTrustedProxy("192.168.1.1:1234"))
+	assert.Equal(test, true, utils.IsTrustedProxy("fd00::1"))
+	assert.Equal(test, false, utils.IsTrustedProxy("192.0.2.1:1234"))
+}
+
+func TestSetTrustedProxies(test *testing.T) {
+	defer utils.SetTrustedProxies(utils.DefaultTrustedProxies...)
+
+	err := utils.SetTrustedProxies("192.0.2.0/24", "2001:db8::1")
+	assert.NoError(test, err)
+	assert.Equal(test, []string{"192.0.2.0/24", "2001:db8::1/128"}, utils.GetTrustedProxies())
+	assert.Equal(test, true, utils.IsTrustedProxy("192.0.2.1:1234"))
+	assert.Equal(test, true, utils.IsTrustedProxy("[2001:db8::1]:1234"))
+	assert.Equal(test, false, utils.IsTrustedProxy("10.1.2.3"))
+
+	err = utils.SetTrustedProxies()
+	assert.NoError(test, err)
+	assert.Equal(test, []string{}, utils.GetTrustedProxies())
+	assert.Equal(test, false, utils.IsTrustedProxy("127.0.0.1"))
+}
+
+func TestFailSetTrustedProxiesWithBadCIDR(test *testing.T) {
+	err := utils.SetTrustedProxies("10.0.0.0/8", "10.0.0.0/99")
+	assert.Error(test, err)
+	assert.Equal(test, "Unable to parse trusted proxy 10.0.0.0/99 as CIDR or IP address", err.Error())
+	assert.Equal(test, true, utils.IsTrustedProxy("127.0.0.1"))
+	assert.Equal(test, false, utils.IsTrustedProxy("10.1.2.3"))
+
+	err = utils.SetTrustedProxies("a-proxy")
+	assert.Error(test, err)
+}
+
+func TestGetClientIP(test *testing.T) {
+	utils.SetTrustedProxies(append(utils.DefaultTrustedProxies, utils.PrivateNetworks...)...)
+	defer utils.SetTrustedProxies(utils.DefaultTrustedProxies...)
+
+	request := httptest.NewRequest(echo.GET, "/", nil)
+	request.Header.Set("X-Forwarded-For", "203.0.113.7")
+	assert.Equal(test, "192.0.2.1", utils.GetClientIP(request))
+
+	request.RemoteAddr = "10.0.0.2:1234"
+	request.Header.Set("X-Forwarded-For", "203.0.113.7, 198.51.100.4, 10.0.0.1")
+	assert.Equal(test, "198.51.100.4", utils.GetClientIP(request))
+
+	request.Header.Del("X-Forwarded-For")
+	request.Header.Set("Forwarded", "for=203.0.113.7, for=\"[2001:db8:cafe::17]:4711\", for=10.0.0.1")
+	assert.Equal(test, "2001:db8:cafe::17", utils.GetClientIP(request))
+
+	request.Header.Del("Forwarded")
+	request.Header.Set("X-Real-Ip", "203.0.113.7")
+	assert.Equal(test, "203.0.113.7", utils.GetClientIP(request))
+
+	request.Header.Del("X-Real-Ip")
+	assert.Equal(test, "10.0.0.2", utils.GetClientIP(request))
+
+	request.Header.Set("X-Forwarded-For", "10.0.0.3, 10.0.0.1")
+	assert.Equal(test, "10.0.0.3", utils.GetClientIP(request))
+}
+
+func TestRemoveUntrustedForwardedHeaders(test *testing.T) {
+	utils.SetTrustedProxies(append(utils.DefaultTrustedProxies, utils.PrivateNetworks...)...)
+	defer utils.SetTrustedProxies(utils.DefaultTrustedProxies...)
+
+	request := httptest.NewRequest(echo.GET, "/", nil)
+	request.Header.Set("X-Forwarded-Host", "attacker.example.com")
+	request.Header.Set("Forwarded", "host=attacker.example.com")
+
+	assert.Equal(test, false, utils.RemoveUntrustedForwardedHeaders(request))
+	assert.Equal(test, "", request.Header.Get("X-Forwarded-Host"))
+	assert.Equal(test, "", request.Header.Get("Forwarded"))
+
+	request.RemoteAddr = "10.0.0.1:1234"
+	request.Header.Set("X-Forwarded-Host", "internt.mojlighetsministeriet.se")
+
+	assert.Equal(test, true, utils.RemoveUntrustedForwardedHeaders(request))
+	assert.Equal(test, "internt.mojlighetsministeriet.se", request.Header.Get("X-Forwarded-Host"))
+}
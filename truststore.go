@@ -0,0 +1,171 @@
+package utils
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// TrustStoreSource tells how many certificates that were loaded from a file
+type TrustStoreSource struct {
+	Path         string `json:"path"`
+	Certificates int    `json:"certificates"`
+}
+
+// TrustStore is a pool of trusted CA certificates together with where they were loaded from
+type TrustStore struct {
+	Pool         *x509.CertPool
+	Sources      []TrustStoreSource
+	certificates []*x509.Certificate
+}
+
+// NewTrustStore loads the certificates in every path, a path can be a file, a directory or a glob pattern such as /etc/ssl/certs/*.pem.
+// An error is returned if a file, or all the files in a directory or glob pattern, contains no certificates.
+func NewTrustStore(includeSystemPool bool, paths ...string) (store *TrustStore, err error) {
+	result := &TrustStore{Pool: x509.NewCertPool()}
+
+	if includeSystemPool {
+		result.Pool, err = x509.SystemCertPool()
+		if err != nil {
+			return
+		}
+	}
+
+	for _, path := range paths {
+		err = result.add(path)
+		if err != nil {
+			return
+		}
+	}
+
+	store = result
+
+	return
+}
+
+// TLSConfig returns a client TLS config that trusts the certificates in the store
+func (store *TrustStore) TLSConfig() *tls.Config {
+	return &tls.Config{RootCAs: store.Pool}
+}
+
+// Certificates returns every certificate that was loaded from the sources, the system pool is not included
+func (store *TrustStore) Certificates() []*x509.Certificate {
+	return append([]*x509.Certificate(nil), store.certificates...)
+}
+
+func (store *TrustStore) add(path string) (err error) {
+	filenames, err := expandTrustStorePath(path)
+	if err != nil {
+		return
+	}
+
+	isSingleFile := len(filenames) == 1 && filenames[0] == path
+	total := 0
+
+	for _, filename := range filenames {
+		var content []byte
+		content, err = ioutil.ReadFile(filename)
+		if err != nil {
+			return
+		}
+
+		certificates := parsePEMCertificates(content)
+		for _, certificate := range certificates {
+			store.Pool.AddCert(certificate)
+		}
+
+		store.certificates = append(store.certificates, certificates...)
+		store.Sources = append(store.Sources, TrustStoreSource{Path: filename, Certificates: len(certificates)})
+		total += len(certificates)
+	}
+
+	if total == 0 {
+		if isSingleFile {
+			err = errors.New("No certificates found in " + path)
+		} else {
+			err = errors.New("No certificates found in the " + strconv.Itoa(len(filenames)) + " files matching " + path)
+		}
+	}
+
+	return
+}
+
+func expandTrustStorePath(path string) (filenames []string, err error) {
+	if strings.ContainsAny(path, "*?[") {
+		var matches []string
+		matches, err = filepath.Glob(path)
+		if err != nil {
+			return
+		}
+
+		filenames = filterRegularFiles(matches)
+		return
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+
+	if !info.IsDir() {
+		filenames = []string{path}
+		return
+	}
+
+	files, err := ioutil.ReadDir(path)
+	if err != nil {
+		return
+	}
+
+	var candidates []string
+	for _, file := range files {
+		if !strings.HasPrefix(file.Name(), ".") {
+			candidates = append(candidates, filepath.Join(path, file.Name()))
+		}
+	}
+
+	filenames = filterRegularFiles(candidates)
+
+	return
+}
+
+func filterRegularFiles(paths []string) (filenames []string) {
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err == nil && !info.IsDir() {
+			filenames = append(filenames, path)
+		}
+	}
+
+	return
+}
+
+// parsePEMCertificates works like x509.CertPool.AppendCertsFromPEM but returns the certificates so they can be counted
+func parsePEMCertificates(content []byte) (certificates []*x509.Certificate) {
+	for len(content) > 0 {
+		var block *pem.Block
+		block, content = pem.Decode(content)
+		if block == nil {
+			break
+		}
+
+		if block.Type != "CERTIFICATE" || len(block.Headers) != 0 {
+			continue
+		}
+
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			continue
+		}
+
+		certificates = append(certificates, certificate)
+	}
+
+	return
+}
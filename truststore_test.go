@@ -0,0 +1,64 @@
+package utils_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mojlighetsministeriet/utils"
+	"github.com/mojlighetsministeriet/utils/tlstest"
+	"github.com/stretchr/testify/assert"
+)
+
+func createCertificatePEM(test *testing.T) []byte {
+	authority, err := tlstest.NewCertificateAuthority()
+	assert.NoError(test, err)
+	return authority.CertificatePEM
+}
+
+func TestNewTrustStoreFromDirectoryAndGlob(test *testing.T) {
+	directory, err := ioutil.TempDir("", "truststore")
+	assert.NoError(test, err)
+	defer os.RemoveAll(directory)
+
+	bundle := append(createCertificatePEM(test), createCertificatePEM(test)...)
+	assert.NoError(test, ioutil.WriteFile(filepath.Join(directory, "bundle.pem"), bundle, 0600))
+	assert.NoError(test, ioutil.WriteFile(filepath.Join(directory, "third.crt"), createCertificatePEM(test), 0600))
+
+	store, err := utils.NewTrustStore(false, filepath.Join(directory, "*.pem"))
+	assert.NoError(test, err)
+	assert.Equal(test, []utils.TrustStoreSource{{Path: filepath.Join(directory, "bundle.pem"), Certificates: 2}}, store.Sources)
+
+	store, err = utils.NewTrustStore(false, directory)
+	assert.NoError(test, err)
+	assert.Equal(test, 3, len(store.Certificates()))
+	assert.Equal(test, 2, len(store.Sources))
+	assert.Equal(test, store.Pool, store.TLSConfig().RootCAs)
+}
+
+func TestNewTrustStoreWithSystemPool(test *testing.T) {
+	store, err := utils.NewTrustStore(true, "/etc/ssl/certs/ca-certificates.crt")
+	assert.NoError(test, err)
+	assert.Equal(test, true, store.Sources[0].Certificates > 10)
+}
+
+func TestFailNewTrustStoreWithFileWithoutCertificates(test *testing.T) {
+	err := ioutil.WriteFile("/tmp/not-a-certificate.pem", []byte("not a certificate"), 0600)
+	assert.NoError(test, err)
+	defer os.Remove("/tmp/not-a-certificate.pem")
+
+	store, err := utils.NewTrustStore(false, "/tmp/not-a-certificate.pem")
+	assert.Error(test, err)
+	assert.Equal(test, "No certificates found in /tmp/not-a-certificate.pem", err.Error())
+	assert.Nil(test, store)
+
+	_, err = utils.GetTLSConfigFromFilename("/tmp/not-a-certificate.pem")
+	assert.Error(test, err)
+}
+
+func TestFailNewTrustStoreWithGlobWithoutMatches(test *testing.T) {
+	_, err := utils.NewTrustStore(false, "/tmp/no-such-directory/*.pem")
+	assert.Error(test, err)
+	assert.Equal(test, "No certificates found in the 0 files matching /tmp/no-such-directory/*.pem", err.Error())
+}
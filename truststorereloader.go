@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"crypto/x509"
+	"sync"
+	"time"
+)
+
+// TrustStoreReloader keeps a TrustStore up to date by reloading it from its paths, see NewTrustStore
+type TrustStoreReloader struct {
+	mutex             sync.RWMutex
+	store             *TrustStore
+	includeSystemPool bool
+	paths             []string
+	stop              chan struct{}
+	stopOnce          sync.Once
+}
+
+// NewTrustStoreReloader loads a TrustStore and reloads it every interval, an interval of 0 leaves reloading to Reload
+func NewTrustStoreReloader(interval time.Duration, includeSystemPool bool, paths ...string) (reloader *TrustStoreReloader, err error) {
+	result := &TrustStoreReloader{includeSystemPool: includeSystemPool, paths: paths, stop: make(chan struct{})}
+
+	err = result.Reload()
+	if err != nil {
+		return
+	}
+
+	if interval > 0 {
+		go result.poll(interval)
+	}
+
+	reloader = result
+
+	return
+}
+
+// Reload loads the trust store again, the previous trust store is kept if it fails to load
+func (reloader *TrustStoreReloader) Reload() (err error) {
+	store, err := NewTrustStore(reloader.includeSystemPool, reloader.paths...)
+	if err != nil {
+		return
+	}
+
+	reloader.mutex.Lock()
+	reloader.store = store
+	reloader.mutex.Unlock()
+
+	return
+}
+
+// TrustStore returns the current trust store
+func (reloader *TrustStoreReloader) TrustStore() *TrustStore {
+	reloader.mutex.RLock()
+	defer reloader.mutex.RUnlock()
+
+	return reloader.store
+}
+
+// Pool returns the current pool of trusted certificates
+func (reloader *TrustStoreReloader) Pool() *x509.CertPool {
+	return reloader.TrustStore().Pool
+}
+
+// Close stops reloading the trust store
+func (reloader *TrustStoreReloader) Close() {
+	reloader.stopOnce.Do(func() {
+		close(reloader.stop)
+	})
+}
+
+func (reloader *TrustStoreReloader) poll(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			reloader.Reload()
+		case <-reloader.stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WatchedFile keeps the contents of a file, such as a rotated secret or certificate, up to date by polling it for changes
+type WatchedFile struct {
+	path      string
+	mutex     sync.RWMutex
+	content   []byte
+	checksum  [sha256.Size]byte
+	modTime   time.Time
+	size      int64
+	callbacks []func(content []byte)
+	stop      chan struct{}
+	stopOnce  sync.Once
+}
+
+// NewWatchedFile reads a file and starts polling it for changes every interval, an interval of 0 disables polling and leaves checking to Check
+func NewWatchedFile(path string, interval time.Duration) (watchedFile *WatchedFile, err error) {
+	result := &WatchedFile{path: path, stop: make(chan struct{})}
+
+	_, err = result.Check()
+	if err != nil {
+		return
+	}
+
+	recordConfigValue(path, result.String(), ConfigSourceFile.String(), true)
+
+	if interval > 0 {
+		go result.poll(interval)
+	}
+
+	watchedFile = result
+
+	return
+}
+
+// Path returns the path of the watched file
+func (watchedFile *WatchedFile) Path() string {
+	return watchedFile.path
+}
+
+// Bytes returns a copy of the current file contents
+func (watchedFile *WatchedFile) Bytes() []byte {
+	watchedFile.mutex.RLock()
+	defer watchedFile.mutex.RUnlock()
+
+	return append([]byte(nil), watchedFile.content...)
+}
+
+// String returns the current file contents trimmed the same way as GetFileAsString
+func (watchedFile *WatchedFile) String() string {
+	watchedFile.mutex.RLock()
+	defer watchedFile.mutex.RUnlock()
+
+	return strings.Trim(string(watchedFile.content), "\n ")
+}
+
+// OnChange registers a callback that will be called with the new contents every time the file changes
+func (watchedFile *WatchedFile) OnChange(callback func(content []byte)) {
+	watchedFile.mutex.Lock()
+	defer watchedFile.mutex.Unlock()
+
+	watchedFile.callbacks = append(watchedFile.callbacks, callback)
+}
+
+// Check reads the file if its modification time or size has changed and calls the registered callbacks if the checksum differs
+func (watchedFile *WatchedFile) Check() (changed bool, err error) {
+	info, err := os.Stat(watchedFile.path)
+	if err != nil {
+		return
+	}
+
+	watchedFile.mutex.RLock()
+	unchanged := watchedFile.content != nil && info.ModTime().Equal(watchedFile.modTime) && info.Size() == watchedFile.size
+	watchedFile.mutex.RUnlock()
+
+	if unchanged {
+		return
+	}
+
+	content, err := ioutil.ReadFile(watchedFile.path)
+	if err != nil {
+		return
+	}
+
+	checksum := sha256.Sum256(content)
+
+	watchedFile.mutex.Lock()
+	changed = watchedFile.content == nil || !bytes.Equal(checksum[:], watchedFile.checksum[:])
+	watchedFile.content = content
+	watchedFile.checksum = checksum
+	watchedFile.modTime = info.ModTime()
+	watchedFile.size = info.Size()
+	callbacks := watchedFile.callbacks
+	watchedFile.mutex.Unlock()
+
+	if changed {
+		for _, callback := range callbacks {
+			callback(append([]byte(nil), content...))
+		}
+	}
+
+	return
+}
+
+// Close stops polling the file
+func (watchedFile *WatchedFile) Close() {
+	watchedFile.stopOnce.Do(func() {
+		close(watchedFile.stop)
+	})
+}
+
+func (watchedFile *WatchedFile) poll(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			// A file that is temporarily missing while being replaced keeps its previous value
+			watchedFile.Check()
+		case <-watchedFile.stop:
+			return
+		}
+	}
+}
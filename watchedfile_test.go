@@ -0,0 +1,64 @@
+package utils_test
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mojlighetsministeriet/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchedFileCallsOnChange(test *testing.T) {
+	err := ioutil.WriteFile("/tmp/a-watched-secret", []byte("first value\n"), 0600)
+	assert.NoError(test, err)
+	defer os.Remove("/tmp/a-watched-secret")
+
+	watchedFile, err := utils.NewWatchedFile("/tmp/a-watched-secret", 10*time.Millisecond)
+	assert.NoError(test, err)
+	defer watchedFile.Close()
+	assert.Equal(test, "first value", watchedFile.String())
+
+	changes := make(chan string, 1)
+	watchedFile.OnChange(func(content []byte) {
+		changes <- string(content)
+	})
+
+	err = ioutil.WriteFile("/tmp/a-watched-secret", []byte("second value"), 0600)
+	assert.NoError(test, err)
+	later := time.Now().Add(time.Minute)
+	os.Chtimes("/tmp/a-watched-secret", later, later)
+
+	select {
+	case content := <-changes:
+		assert.Equal(test, "second value", content)
+	case <-time.After(time.Second):
+		test.Fatal("the change callback was never called")
+	}
+
+	assert.Equal(test, "second value", watchedFile.String())
+	assert.Equal(test, []byte("second value"), watchedFile.Bytes())
+}
+
+func TestWatchedFileIgnoresTouchWithoutChange(test *testing.T) {
+	err := ioutil.WriteFile("/tmp/a-touched-secret", []byte("a value"), 0600)
+	assert.NoError(test, err)
+	defer os.Remove("/tmp/a-touched-secret")
+
+	watchedFile, err := utils.NewWatchedFile("/tmp/a-touched-secret", 0)
+	assert.NoError(test, err)
+
+	later := time.Now().Add(time.Minute)
+	os.Chtimes("/tmp/a-touched-secret", later, later)
+
+	changed, err := watchedFile.Check()
+	assert.NoError(test, err)
+	assert.Equal(test, false, changed)
+}
+
+func TestFailNewWatchedFileWithMissingFile(test *testing.T) {
+	watchedFile, err := utils.NewWatchedFile("/tmp/a-missing-watched-secret", time.Second)
+	assert.Error(test, err)
+	assert.Nil(test, watchedFile)
+}